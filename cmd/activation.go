@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var activateCmd = &cobra.Command{
+	Use:   "activate",
+	Short: "Activate a seat-limited license on this machine",
+	Long: `Activate a seat-limited license (with the "max_activations" metadata) on this machine: the licensor API takes
+a seat and returns a signed activation token, stored locally so that validate accepts the license on this machine.`,
+	PreRun: bindFlags,
+	RunE:   activateLicense,
+}
+
+var deactivateCmd = &cobra.Command{
+	Use:    "deactivate",
+	Short:  "Release the seat taken by a license on this machine, so that it can be activated on another one",
+	PreRun: bindFlags,
+	RunE:   deactivateLicense,
+}
+
+// activation is the result document of activate (and the activation of the validation result document)
+type activation struct {
+	Serial      string `json:"serial" yaml:"serial"`
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+	ActivatedOn string `json:"activated_on" yaml:"activated_on"`
+	ExpiresOn   string `json:"expires_on,omitempty" yaml:"expires_on,omitempty"`
+}
+
+// deactivation is the result document of deactivate
+type deactivation struct {
+	Serial      string `json:"serial" yaml:"serial"`
+	Deactivated bool   `json:"deactivated" yaml:"deactivated"`
+}
+
+// Building the activation document
+func newActivation(a *license.Activation) *activation {
+	return &activation{
+		Serial:      a.Serial,
+		Fingerprint: a.Fingerprint,
+		ActivatedOn: formatDate(a.ActivatedOn),
+		ExpiresOn:   formatDate(a.ExpiresOn),
+	}
+}
+
+func activateLicense(cmd *cobra.Command, args []string) error {
+	serial, opts, err := activationRequest(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	activated, err := license.Activate(ctx, serial, opts...)
+	if err != nil {
+		return errors.Wrap(err, "Unable to activate license")
+	}
+	result := newActivation(activated)
+	report(result, func() {
+		if result.ExpiresOn == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "License %q activated on this machine (%s)\n", result.Serial, result.Fingerprint)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "License %q activated on this machine (%s) until %s\n", result.Serial, result.Fingerprint, result.ExpiresOn)
+	})
+	return nil
+}
+
+func deactivateLicense(cmd *cobra.Command, args []string) error {
+	serial, opts, err := activationRequest(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	if err := license.Deactivate(ctx, serial, opts...); err != nil {
+		return errors.Wrap(err, "Unable to deactivate license")
+	}
+	result := deactivation{Serial: serial, Deactivated: true}
+	report(result, func() {
+		fmt.Fprintf(cmd.OutOrStdout(), "License %q deactivated, its seat is released\n", result.Serial)
+	})
+	return nil
+}
+
+// Verifying the seat-limited license and building the options of activate and deactivate
+// Only a license signed by a trusted key and carrying the "max_activations" metadata is sent to the licensor API
+func activationRequest(cmd *cobra.Command) (string, []license.Option, error) {
+	if viper.GetString("license") == "" {
+		return "", nil, &exitError{code: ExitInvalidOption, err: errors.New("Missing license (--license)")}
+	}
+	opts, err := licenseOptions()
+	if err != nil {
+		return "", nil, err
+	}
+	fingerprintOpts, _, err := fingerprintOptions()
+	if err != nil {
+		return "", nil, err
+	}
+	opts = append(opts, license.WithFingerprint(fingerprintOpts...), license.WithActivationURL(viper.GetString("activation_url")))
+	opts = append(opts, activationOptions()...)
+	cmd.SilenceUsage = true
+	ctx, cancel := commandContext()
+	defer cancel()
+	verified, err := license.NewFromSource(ctx, argumentSource(viper.GetString("license")), opts...)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Unable to initialize License")
+	}
+	// The other checks (Eg: the activation itself) don't matter here
+	result, _ := verified.Validate(nil)
+	for _, check := range result.Checks {
+		if check.Name == license.CheckSignature && !check.Passed {
+			return "", nil, errors.Wrap(check.Err(), "Untrusted license")
+		}
+	}
+	if _, limited := verified.Meta[license.MetaMaxActivations]; !limited {
+		return "", nil, &exitError{code: ExitInvalidOption, err: errors.Errorf("License %q is not seat-limited (no %q metadata)", verified.Serial, license.MetaMaxActivations)}
+	}
+	return verified.Serial, opts, nil
+}
+
+// Registering the flags selecting where the activation tokens are stored
+func addActivationFlags(cmd *cobra.Command) {
+	cmd.Flags().String("activation_dir", "", "The directory of the activation tokens of seat-limited licenses (user config directory by default)")
+}
+
+// Building the options selecting where the activation tokens are stored (default directory if --activation_dir is empty)
+func activationOptions() []license.Option {
+	if dir := viper.GetString("activation_dir"); dir != "" {
+		return []license.Option{license.WithActivationStore(dir)}
+	}
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{activateCmd, deactivateCmd} {
+		cmd.Flags().StringP("license", "l", "", "The license: an URL, a path, the content itself, \"-\" (stdin), \"env:NAME\" or \"file:PATH\"")
+		cmd.Flags().String("activation_url", license.DefaultActivationURL, "The licensor API endpoint of the activations (\"/activate\" and \"/deactivate\" are appended)")
+		addKeyFlags(cmd)
+		addFingerprintFlags(cmd)
+		addActivationFlags(cmd)
+		rootCmd.AddCommand(cmd)
+	}
+}
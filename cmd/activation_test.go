@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+func TestActivationRequest(t *testing.T) {
+	defer viper.Reset()
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("public_key", "../test/assets/public.key")
+	viper.Set("fingerprint_components", "hostname")
+	viper.Set("fingerprint_hash", "sha256")
+	viper.Set("activation_dir", t.TempDir())
+	viper.Set("activation_url", license.DefaultActivationURL)
+	// A forged license doesn't reach the licensor API
+	viper.Set("license", strings.Replace(string(content), "foo-test-alpha", "foo-test-omega", 1))
+	if _, _, err := activationRequest(activateCmd); exitCode(err) != ExitInvalidSignature {
+		t.Errorf("Expected ExitInvalidSignature for a forged license, got %v", err)
+	}
+	// Neither does a genuine license which is not seat-limited
+	viper.Set("license", "../test/assets/license.txt")
+	if _, _, err := activationRequest(activateCmd); exitCode(err) != ExitInvalidOption {
+		t.Errorf("Expected ExitInvalidOption for a license without max_activations, got %v", err)
+	}
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [directory|glob|file]...",
+	Short: "Validate many licenses concurrently and report the outcome of each one with totals",
+	Long: `Validate many licenses concurrently and report the outcome of each one with totals.
+Licenses are every file of the given directories, the files matching the given globs (Eg: "licenses/*.txt"),
+the given files and the paths listed by --list (one per line). The public key and the revocation list are fetched once for all licenses.`,
+	Annotations: map[string]string{annotationCSV: "true"},
+	PreRun:      bindFlags,
+	RunE:        auditLicenses,
+}
+
+// Audit categories, counted by the totals of the report
+const (
+	CategoryValid            = "valid"
+	CategoryExpired          = "expired"
+	CategoryRevoked          = "revoked"
+	CategoryInactive         = "inactive"
+	CategoryWrongMachine     = "wrong_machine"
+	CategoryNotActivated     = "not_activated"
+	CategoryInvalidSignature = "invalid_signature"
+	CategoryMetadataMismatch = "meta_mismatch"
+	CategoryPolicyViolation  = "policy_violation"
+	CategoryInvalid          = "invalid"
+)
+
+// auditedLicense is the outcome of the validation of a license
+type auditedLicense struct {
+	License  string         `json:"license" yaml:"license"`
+	Serial   string         `json:"serial" yaml:"serial"`
+	Category string         `json:"category" yaml:"category"`
+	Status   license.Status `json:"status,omitempty" yaml:"status,omitempty"`
+	ExitCode int            `json:"exit_code" yaml:"exit_code"`
+	Error    string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// auditTotals counts the audited licenses by category
+type auditTotals struct {
+	Total            int `json:"total" yaml:"total"`
+	Valid            int `json:"valid" yaml:"valid"`
+	Expired          int `json:"expired" yaml:"expired"`
+	Revoked          int `json:"revoked" yaml:"revoked"`
+	Inactive         int `json:"inactive" yaml:"inactive"`
+	WrongMachine     int `json:"wrong_machine" yaml:"wrong_machine"`
+	NotActivated     int `json:"not_activated" yaml:"not_activated"`
+	InvalidSignature int `json:"invalid_signature" yaml:"invalid_signature"`
+	MetadataMismatch int `json:"meta_mismatch" yaml:"meta_mismatch"`
+	PolicyViolation  int `json:"policy_violation" yaml:"policy_violation"`
+	Invalid          int `json:"invalid" yaml:"invalid"`
+}
+
+// audit is the result document of audit
+type audit struct {
+	Licenses []auditedLicense `json:"licenses" yaml:"licenses"`
+	Totals   auditTotals      `json:"totals" yaml:"totals"`
+}
+
+func auditLicenses(cmd *cobra.Command, args []string) error {
+	paths, err := auditPaths(args, viper.GetString("list"))
+	if err != nil {
+		return &exitError{code: ExitInvalidOption, err: err}
+	}
+	if len(paths) == 0 {
+		return &exitError{code: ExitInvalidOption, err: errors.New("No license to audit (give directories, globs, files or --list)")}
+	}
+	workers := viper.GetInt("workers")
+	if workers < 1 {
+		return &exitError{code: ExitInvalidOption, err: errors.Errorf("Invalid --workers %d (at least 1 expected)", workers)}
+	}
+	policy, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	opts, err := licenseOptions()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, onlineOptions()...)
+	opts = append(opts, activationOptions()...)
+	fingerprintOpts, _, err := fingerprintOptions()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, license.WithFingerprint(fingerprintOpts...))
+	opts = append(opts,
+		license.WithGracePeriod(viper.GetDuration("grace_period")),
+		license.WithWarningPeriod(viper.GetDuration("warning_period")),
+	)
+	cmd.SilenceUsage = true
+	// Fetching the keys once, shared by every license
+	ctx, cancel := commandContext()
+	defer cancel()
+	keyring, err := license.LoadKeyring(ctx, opts...)
+	if err != nil {
+		return errors.Wrap(err, "Unable to load public key")
+	}
+	opts = append(opts, license.WithKeyring(keyring))
+	// Fetching the revocation list once too
+	if revocationOpts := revocationOptions(); revocationOpts != nil {
+		revocations, err := license.LoadRevocationList(ctx, append(opts, revocationOpts...)...)
+		if err != nil {
+			return errors.Wrap(err, "Unable to load revocation list")
+		}
+		opts = append(opts, license.WithRevocationList(revocations))
+	}
+	// Validating the licenses concurrently, the report keeps their order
+	result := audit{Licenses: make([]auditedLicense, len(paths))}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result.Licenses[index] = auditLicense(ctx, paths[index], policy, opts)
+			}
+		}()
+	}
+	for index := range paths {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	for _, audited := range result.Licenses {
+		result.Totals.add(audited.Category)
+	}
+	if viper.GetString("output") == OutputCSV {
+		if err := writeAuditCSV(cmd.OutOrStdout(), result); err != nil {
+			return err
+		}
+	} else {
+		report(result, func() {
+			printAudit(cmd.OutOrStdout(), result)
+		})
+	}
+	if result.Totals.Valid != result.Totals.Total {
+		return &exitError{code: ExitFailure}
+	}
+	return nil
+}
+
+// Validating a license of the audit
+func auditLicense(ctx context.Context, path string, policy license.Policy, opts []license.Option) auditedLicense {
+	audited := auditedLicense{License: path, Category: CategoryValid}
+	parsed, err := license.NewFromSource(ctx, license.FromFile(path), opts...)
+	if err == nil {
+		audited.Serial = parsed.Serial
+		var result *license.ValidationResult
+		result, err = parsed.ValidateWithPolicy(policy)
+		audited.Status = result.Validity.Status
+		if err == nil {
+			audited.ExitCode = statusExitCode(result.Validity.Status)
+			return audited
+		}
+	}
+	audited.ExitCode = exitCode(err)
+	audited.Error = err.Error()
+	switch audited.ExitCode {
+	case ExitExpired:
+		audited.Category = CategoryExpired
+	case ExitRevoked:
+		audited.Category = CategoryRevoked
+	case ExitInactive:
+		audited.Category = CategoryInactive
+	case ExitFingerprintMismatch:
+		audited.Category = CategoryWrongMachine
+	case ExitNotActivated:
+		audited.Category = CategoryNotActivated
+	case ExitInvalidSignature:
+		audited.Category = CategoryInvalidSignature
+	case ExitMetadataMismatch:
+		audited.Category = CategoryMetadataMismatch
+	case ExitPolicyViolation:
+		audited.Category = CategoryPolicyViolation
+	default:
+		audited.Category = CategoryInvalid
+	}
+	return audited
+}
+
+// Counting an audited license
+func (t *auditTotals) add(category string) {
+	t.Total++
+	switch category {
+	case CategoryValid:
+		t.Valid++
+	case CategoryExpired:
+		t.Expired++
+	case CategoryRevoked:
+		t.Revoked++
+	case CategoryInactive:
+		t.Inactive++
+	case CategoryWrongMachine:
+		t.WrongMachine++
+	case CategoryNotActivated:
+		t.NotActivated++
+	case CategoryInvalidSignature:
+		t.InvalidSignature++
+	case CategoryMetadataMismatch:
+		t.MetadataMismatch++
+	case CategoryPolicyViolation:
+		t.PolicyViolation++
+	default:
+		t.Invalid++
+	}
+}
+
+// Listing the licenses to audit: files of directories, files matching globs, files and paths listed by a file ("-" for stdin)
+// Every path is audited once, in the given order
+func auditPaths(args []string, list string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			entries, err := os.ReadDir(arg)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Unable to list %q", arg)
+			}
+			for _, entry := range entries {
+				if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+					add(filepath.Join(arg, entry.Name()))
+				}
+			}
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid glob %q", arg)
+		}
+		// A missing file is reported as an invalid license rather than silently skipped
+		if len(matches) == 0 {
+			matches = []string{arg}
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	if list == "" {
+		return paths, nil
+	}
+	reader := io.Reader(os.Stdin)
+	if list != "-" {
+		file, err := os.Open(list)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to read list %q", list)
+		}
+		defer file.Close()
+		reader = file
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		// Blank lines and comments are ignored
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			add(line)
+		}
+	}
+	return paths, errors.Wrapf(scanner.Err(), "Unable to read list %q", list)
+}
+
+// Printing the audit as a human friendly report
+func printAudit(out io.Writer, result audit) {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, audited := range result.Licenses {
+		outcome := string(audited.Status)
+		if audited.Error != "" {
+			outcome = audited.Error
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", audited.Category, audited.License, audited.Serial, outcome)
+	}
+	writer.Flush()
+	totals := result.Totals
+	fmt.Fprintf(out, "\nTotal: %d, valid: %d, expired: %d, revoked: %d, inactive: %d, wrong machine: %d, not activated: %d, invalid signature: %d, meta mismatch: %d, policy violation: %d, invalid: %d\n",
+		totals.Total, totals.Valid, totals.Expired, totals.Revoked, totals.Inactive, totals.WrongMachine, totals.NotActivated, totals.InvalidSignature, totals.MetadataMismatch, totals.PolicyViolation, totals.Invalid)
+}
+
+// Writing the audit as CSV, one row per license followed by one "total,<category>,<count>" row per category
+// (padded to the width of the header, so that CSV readers accept them)
+func writeAuditCSV(out io.Writer, result audit) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"license", "serial", "category", "status", "exit_code", "error"})
+	for _, audited := range result.Licenses {
+		writer.Write([]string{audited.License, audited.Serial, audited.Category, string(audited.Status), strconv.Itoa(audited.ExitCode), audited.Error})
+	}
+	totals := result.Totals
+	for _, total := range []struct {
+		category string
+		count    int
+	}{
+		{"total", totals.Total},
+		{CategoryValid, totals.Valid},
+		{CategoryExpired, totals.Expired},
+		{CategoryRevoked, totals.Revoked},
+		{CategoryInactive, totals.Inactive},
+		{CategoryWrongMachine, totals.WrongMachine},
+		{CategoryNotActivated, totals.NotActivated},
+		{CategoryInvalidSignature, totals.InvalidSignature},
+		{CategoryMetadataMismatch, totals.MetadataMismatch},
+		{CategoryPolicyViolation, totals.PolicyViolation},
+		{CategoryInvalid, totals.Invalid},
+	} {
+		writer.Write([]string{"total", total.category, strconv.Itoa(total.count), "", "", ""})
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "Unable to write CSV report")
+}
+
+func init() {
+	auditCmd.Flags().String("list", "", "A file listing the licenses to audit, one path per line (\"-\" for stdin)")
+	auditCmd.Flags().Int("workers", runtime.NumCPU(), "How many licenses are validated concurrently")
+	auditCmd.Flags().StringArrayP("meta", "m", nil, metaUsage)
+	auditCmd.Flags().String("policy", "", policyUsage)
+	addKeyFlags(auditCmd)
+	addRevocationFlags(auditCmd)
+	addOnlineFlags(auditCmd)
+	addFingerprintFlags(auditCmd)
+	addActivationFlags(auditCmd)
+	auditCmd.Flags().Duration("grace_period", 0, "How long an expired license is still accepted (with a warning) after its expiration (Eg: 168h)")
+	auditCmd.Flags().Duration("warning_period", 0, "How long before its expiration a license is reported as expiring soon (Eg: 720h)")
+	rootCmd.AddCommand(auditCmd)
+}
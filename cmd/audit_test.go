@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+func TestWriteAuditCSV(t *testing.T) {
+	result := audit{Licenses: []auditedLicense{
+		{License: "a.txt", Serial: "A", Category: CategoryValid, Status: license.StatusValid},
+		{License: "b.txt", Serial: "B", Category: CategoryExpired, Status: license.StatusExpired, ExitCode: ExitExpired, Error: "License expired"},
+		{License: "c.txt", Category: CategoryInvalid, ExitCode: ExitInvalidFormat, Error: "Invalid license format"},
+	}}
+	for _, audited := range result.Licenses {
+		result.Totals.add(audited.Category)
+	}
+	var out bytes.Buffer
+	if err := writeAuditCSV(&out, result); err != nil {
+		t.Fatal(err)
+	}
+	// Every row has the width of the header, which the reader enforces
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+len(result.Licenses)+11 {
+		t.Fatalf("Expected a header, %d licenses and 11 totals, got %d rows", len(result.Licenses), len(rows))
+	}
+	if rows[2][0] != "b.txt" || rows[2][2] != CategoryExpired || rows[2][4] != "4" {
+		t.Errorf("Unexpected license row %v", rows[2])
+	}
+	totals := map[string]string{}
+	for _, row := range rows[1+len(result.Licenses):] {
+		if row[0] != "total" {
+			t.Fatalf("Expected a total row, got %v", row)
+		}
+		totals[row[1]] = row[2]
+	}
+	expected := map[string]string{"total": "3", CategoryValid: "1", CategoryExpired: "1", CategoryInvalid: "1", CategoryRevoked: "0", CategoryNotActivated: "0"}
+	for category, count := range expected {
+		if totals[category] != count {
+			t.Errorf("Expected %s total %s, got %q", category, count, totals[category])
+		}
+	}
+}
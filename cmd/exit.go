@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+// Exit codes returned by buymint-cli (documented into README.md)
+const (
+	// ExitOK means the command succeeded (and the license is valid)
+	ExitOK = 0
+	// ExitFailure means a generic failure
+	ExitFailure = 1
+	// ExitExpiringSoon means the license is valid but expires within the warning period
+	ExitExpiringSoon = 2
+	// ExitInGrace means the license is expired but still accepted within the grace period
+	ExitInGrace = 3
+	// ExitExpired means the license is expired
+	ExitExpired = 4
+	// ExitNotYetValid means the license is used before it was signed
+	ExitNotYetValid = 5
+	// ExitInvalidFormat means the license is malformed
+	ExitInvalidFormat = 6
+	// ExitInvalidSignature means the license signature doesn't match its content
+	ExitInvalidSignature = 7
+	// ExitInvalidKey means the public key is malformed or not supported
+	ExitInvalidKey = 8
+	// ExitMetadataMismatch means a desired metadata is missing or different into the license
+	ExitMetadataMismatch = 9
+	// ExitFetch means the license or the public key could not be fetched
+	ExitFetch = 10
+	// ExitInvalidOption means the command was invoked with a wrong option
+	ExitInvalidOption = 11
+	// ExitKeyRetired means the license is signed with a retired or revoked key
+	ExitKeyRetired = 12
+	// ExitUntrustedKey means the public key is not the pinned one or changed since it was cached
+	ExitUntrustedKey = 13
+	// ExitPolicyViolation means the license doesn't satisfy the policy (issuer, key ID, algorithm, remaining validity)
+	ExitPolicyViolation = 14
+	// ExitRevoked means the license is listed by the revocation list
+	ExitRevoked = 15
+	// ExitInactive means the licensor API reports the license is not active (suspended, refunded, ...)
+	ExitInactive = 16
+	// ExitFingerprintMismatch means the node-locked license is bound to another machine
+	ExitFingerprintMismatch = 17
+	// ExitNotActivated means the seat-limited license is not activated on this machine
+	ExitNotActivated = 18
+	// ExitRevocationListExpired means the revocation list is past its expiry date and no newer one could be read
+	ExitRevocationListExpired = 19
+)
+
+// Exit codes of every kind of license failure (checked in order)
+var errorExitCodes = []struct {
+	err  error
+	code int
+}{
+	{license.ErrRevoked, ExitRevoked},
+	{license.ErrRevocationListExpired, ExitRevocationListExpired},
+	{license.ErrInactive, ExitInactive},
+	{license.ErrFingerprintMismatch, ExitFingerprintMismatch},
+	{license.ErrNotActivated, ExitNotActivated},
+	{license.ErrExpired, ExitExpired},
+	{license.ErrNotYetValid, ExitNotYetValid},
+	{license.ErrInvalidFormat, ExitInvalidFormat},
+	{license.ErrInvalidSignature, ExitInvalidSignature},
+	{license.ErrInvalidKey, ExitInvalidKey},
+	{license.ErrKeyRetired, ExitKeyRetired},
+	{license.ErrUntrustedKey, ExitUntrustedKey},
+	{license.ErrMetadataMismatch, ExitMetadataMismatch},
+	{license.ErrPolicyViolation, ExitPolicyViolation},
+	{license.ErrFetch, ExitFetch},
+	{license.ErrInvalidOption, ExitInvalidOption},
+}
+
+// exitError carries the exit code the process must return; a nil err means no message to print
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// Mapping the validity status of a license to its exit code
+func statusExitCode(status license.Status) int {
+	switch status {
+	case license.StatusExpiringSoon:
+		return ExitExpiringSoon
+	case license.StatusInGrace:
+		return ExitInGrace
+	case license.StatusExpired:
+		return ExitExpired
+	case license.StatusNotYetValid:
+		return ExitNotYetValid
+	}
+	return ExitOK
+}
+
+// Mapping an error to the exit code the process must return
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	for _, errorExitCode := range errorExitCodes {
+		if errors.Is(err, errorExitCode.err) {
+			return errorExitCode.code
+		}
+	}
+	return ExitFailure
+}
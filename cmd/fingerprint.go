@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/pkg/fingerprint"
+)
+
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "Print the fingerprint of this machine, to send to the licensor for a node-locked license",
+	Long: `Print the fingerprint of this machine, to send to the licensor for a node-locked license.
+The licensor writes it into the "fingerprint" metadata of the license (Eg: buymint-cli sign --fingerprint <id>),
+then validate accepts the license on this machine only. Components, hash and salt must be the same on both sides.`,
+	PreRun: bindFlags,
+	RunE:   printFingerprint,
+}
+
+// machineFingerprint is the result document of fingerprint
+type machineFingerprint struct {
+	Fingerprint string            `json:"fingerprint" yaml:"fingerprint"`
+	Components  []string          `json:"components" yaml:"components"`
+	Values      map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+func printFingerprint(cmd *cobra.Command, args []string) error {
+	opts, components, err := fingerprintOptions()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	id, err := fingerprint.Compute(opts...)
+	if err != nil {
+		return err
+	}
+	result := machineFingerprint{Fingerprint: id}
+	for _, component := range components {
+		result.Components = append(result.Components, string(component))
+	}
+	// Raw values may be personal (Eg: hostname), they are shown on demand only
+	if viper.GetBool("show_components") {
+		values, err := fingerprint.Collect(components...)
+		if err != nil {
+			return err
+		}
+		result.Values = map[string]string{}
+		for component, value := range values {
+			result.Values[string(component)] = value
+		}
+	}
+	report(result, func() {
+		fmt.Fprintln(cmd.OutOrStdout(), result.Fingerprint)
+		if result.Values != nil {
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			for _, component := range result.Components {
+				fmt.Fprintf(writer, "%s\t%s\n", component, result.Values[component])
+			}
+			writer.Flush()
+		}
+	})
+	return nil
+}
+
+// Registering the flags configuring how the fingerprint of the machine is computed
+func addFingerprintFlags(cmd *cobra.Command) {
+	components := make([]string, 0, len(fingerprint.DefaultComponents))
+	for _, component := range fingerprint.DefaultComponents {
+		components = append(components, string(component))
+	}
+	cmd.Flags().StringSlice("fingerprint_components", components, "The components of the machine fingerprint: machine_id, mac, cpu and hostname")
+	cmd.Flags().String("fingerprint_hash", "sha256", "The hash of the machine fingerprint: sha256 or sha512")
+	cmd.Flags().String("fingerprint_salt", "", "The salt of the machine fingerprint (HMAC), so that it differs from an application to another")
+}
+
+// Building the options of the machine fingerprint, with its components
+func fingerprintOptions() ([]fingerprint.Option, []fingerprint.Component, error) {
+	var components []fingerprint.Component
+	for _, value := range viper.GetStringSlice("fingerprint_components") {
+		component, err := fingerprint.ParseComponent(value)
+		if err != nil {
+			return nil, nil, &exitError{code: ExitInvalidOption, err: err}
+		}
+		components = append(components, component)
+	}
+	if len(components) == 0 {
+		return nil, nil, &exitError{code: ExitInvalidOption, err: errors.New("No fingerprint component (--fingerprint_components)")}
+	}
+	hash, err := fingerprint.ParseHash(viper.GetString("fingerprint_hash"))
+	if err != nil {
+		return nil, nil, &exitError{code: ExitInvalidOption, err: err}
+	}
+	opts := []fingerprint.Option{fingerprint.WithComponents(components...), fingerprint.WithHash(hash)}
+	if salt := viper.GetString("fingerprint_salt"); salt != "" {
+		opts = append(opts, fingerprint.WithSalt([]byte(salt)))
+	}
+	return opts, components, nil
+}
+
+func init() {
+	addFingerprintFlags(fingerprintCmd)
+	fingerprintCmd.Flags().Bool("show_components", false, "Also print the raw value of every component")
+	rootCmd.AddCommand(fingerprintCmd)
+}
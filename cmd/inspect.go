@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:    "inspect",
+	Short:  "Decode and display a license (headers, metadata and signature), without validating it",
+	PreRun: bindFlags,
+	RunE:   inspectLicense,
+}
+
+// inspection is the decoded content of a license
+type inspection struct {
+	Serial         string                 `json:"serial" yaml:"serial"`
+	LicensedTo     license.Identity       `json:"licensed_to" yaml:"licensed_to"`
+	Meta           map[string]interface{} `json:"meta" yaml:"meta"`
+	TransactionID  string                 `json:"transaction_id" yaml:"transaction_id"`
+	SubscriptionID string                 `json:"subscription_id" yaml:"subscription_id"`
+	ExpiresOn      string                 `json:"expires_on" yaml:"expires_on"`
+	SignedOn       string                 `json:"signed_on" yaml:"signed_on"`
+	IssuedBy       license.Identity       `json:"issued_by" yaml:"issued_by"`
+	IssuedFor      string                 `json:"issued_for" yaml:"issued_for"`
+	Algorithm      license.Algorithm      `json:"algorithm" yaml:"algorithm"`
+	KeyID          string                 `json:"key_id" yaml:"key_id"`
+	Status         license.Status         `json:"status" yaml:"status"`
+	Signature      signatureInspection    `json:"signature" yaml:"signature"`
+}
+
+// signatureInspection tells whether the license signature verifies and with which key
+type signatureInspection struct {
+	Verified       bool   `json:"verified" yaml:"verified"`
+	KeyID          string `json:"key_id,omitempty" yaml:"key_id,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty" yaml:"key_fingerprint,omitempty"`
+	Reason         string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func inspectLicense(cmd *cobra.Command, args []string) error {
+	if viper.GetString("license") == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing license (--license)")}
+	}
+	opts, err := licenseOptions()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	ctx, cancel := commandContext()
+	defer cancel()
+	content, err := readArgument(ctx, viper.GetString("license"))
+	if err != nil {
+		return errors.Wrap(err, "Unable to read license")
+	}
+	parsed, err := license.Parse(content)
+	if err != nil {
+		return err
+	}
+	result := inspection{
+		Serial:         parsed.Serial,
+		LicensedTo:     parsed.LicensedTo,
+		Meta:           parsed.Meta,
+		TransactionID:  parsed.TransactionID,
+		SubscriptionID: parsed.SubscriptionID,
+		ExpiresOn:      formatDate(parsed.ExpiresOn),
+		SignedOn:       formatDate(parsed.SignedOn),
+		IssuedBy:       parsed.IssuedBy,
+		IssuedFor:      parsed.IssuedFor,
+		Algorithm:      parsed.Algorithm,
+		KeyID:          parsed.KeyID,
+		Status:         parsed.Validity().Status,
+		Signature:      inspectSignature(cmd, content, opts),
+	}
+	var printErr error
+	report(result, func() {
+		printErr = printInspection(cmd.OutOrStdout(), result)
+	})
+	return printErr
+}
+
+// Verifying the signature of the license with the configured keys; failing to load them is reported, not returned
+func inspectSignature(cmd *cobra.Command, content []byte, opts []license.Option) signatureInspection {
+	ctx, cancel := commandContext()
+	defer cancel()
+	verified, err := license.NewFromSource(ctx, license.FromString(string(content)), opts...)
+	if err != nil {
+		return signatureInspection{Reason: err.Error()}
+	}
+	result, _ := verified.Validate(nil)
+	for _, check := range result.Checks {
+		if check.Name == license.CheckSignature && !check.Passed {
+			return signatureInspection{Reason: check.Reason}
+		}
+	}
+	inspected := signatureInspection{Verified: true}
+	if key, err := verified.SigningKey(); err == nil {
+		inspected.KeyID = key.ID
+		inspected.KeyFingerprint, _ = license.Fingerprint(key.Key)
+	}
+	return inspected
+}
+
+// Printing the inspection as a human friendly table
+func printInspection(out io.Writer, result inspection) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	row := func(name string, value interface{}) {
+		fmt.Fprintf(writer, "%s\t%v\n", name, value)
+	}
+	row("Serial", result.Serial)
+	row("Licensed to", formatIdentity(result.LicensedTo))
+	row("Transaction ID", result.TransactionID)
+	row("Subscription ID", result.SubscriptionID)
+	row("Expires on", result.ExpiresOn)
+	row("Signed on", result.SignedOn)
+	row("Issued by", formatIdentity(result.IssuedBy))
+	row("Issued for", result.IssuedFor)
+	row("Algorithm", result.Algorithm)
+	row("Key ID", result.KeyID)
+	row("Status", result.Status)
+	// Metadata are sorted to offer a stable output
+	keys := make([]string, 0, len(result.Meta))
+	for key := range result.Meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		row("Metadata", "")
+	}
+	for i, key := range keys {
+		value, err := json.Marshal(result.Meta[key])
+		if err != nil {
+			return errors.Wrapf(err, "Unable to format metadata %q", key)
+		}
+		name := ""
+		if i == 0 {
+			name = "Metadata"
+		}
+		row(name, key+" = "+string(value))
+	}
+	if result.Signature.Verified {
+		row("Signature", "verified")
+		row("Signing key ID", result.Signature.KeyID)
+		row("Signing key fingerprint", result.Signature.KeyFingerprint)
+	} else {
+		row("Signature", "NOT verified: "+result.Signature.Reason)
+	}
+	return writer.Flush()
+}
+
+// Formatting an identity for display (empty for a zero identity)
+func formatIdentity(identity license.Identity) string {
+	if identity == (license.Identity{}) {
+		return ""
+	}
+	return identity.String()
+}
+
+func init() {
+	inspectCmd.Flags().StringP("license", "l", "", "The license: an URL, a path, the content itself, \"-\" (stdin), \"env:NAME\" or \"file:PATH\"")
+	addKeyFlags(inspectCmd)
+	rootCmd.AddCommand(inspectCmd)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var keygenCmd = &cobra.Command{
+	Use:    "keygen",
+	Short:  "Generate a licensor key pair (private key to sign licenses, public key to validate them)",
+	PreRun: bindFlags,
+	RunE:   keygen,
+}
+
+// generatedKey is the result document of keygen
+type generatedKey struct {
+	PrivateKey  string            `json:"private_key" yaml:"private_key"`
+	PublicKey   string            `json:"public_key" yaml:"public_key"`
+	Encrypted   bool              `json:"encrypted" yaml:"encrypted"`
+	Algorithm   license.Algorithm `json:"algorithm" yaml:"algorithm"`
+	Fingerprint string            `json:"fingerprint" yaml:"fingerprint"`
+	KeyID       string            `json:"key_id" yaml:"key_id"`
+}
+
+func keygen(cmd *cobra.Command, args []string) error {
+	privateKeyPath, publicKeyPath := viper.GetString("private_key_out"), viper.GetString("public_key_out")
+	if privateKeyPath == "" || publicKeyPath == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing output path (--private_key_out, --public_key_out)")}
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	algorithm := license.Algorithm(viper.GetString("algorithm"))
+	key, err := license.GenerateKey(algorithm, viper.GetInt("bits"))
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	privateKey, err := license.EncodePrivateKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	publicKey, err := license.EncodePublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+	fingerprint, err := license.Fingerprint(key.Public())
+	if err != nil {
+		return err
+	}
+	keyID, err := license.KeyID(key.Public())
+	if err != nil {
+		return err
+	}
+	// The private key is readable by current user only
+	force := viper.GetBool("force")
+	if err := writeKeyFile(privateKeyPath, privateKey, 0600, force); err != nil {
+		return err
+	}
+	if err := writeKeyFile(publicKeyPath, publicKey, 0644, force); err != nil {
+		return err
+	}
+	result := generatedKey{
+		PrivateKey:  privateKeyPath,
+		PublicKey:   publicKeyPath,
+		Encrypted:   len(passphrase) > 0,
+		Algorithm:   algorithm,
+		Fingerprint: fingerprint,
+		KeyID:       keyID,
+	}
+	report(result, func() {
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Private key: %s", result.PrivateKey)
+		if result.Encrypted {
+			fmt.Fprint(out, " (encrypted)")
+		}
+		fmt.Fprintf(out, "\nPublic key: %s\nAlgorithm: %s\nFingerprint: %s\nKey ID: %s\n", result.PublicKey, result.Algorithm, result.Fingerprint, result.KeyID)
+	})
+	return nil
+}
+
+// Writing a key file, refusing to overwrite an existing one unless forced
+func writeKeyFile(path string, content []byte, perm os.FileMode, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, perm)
+	if os.IsExist(err) {
+		return &exitError{code: ExitInvalidOption, err: errors.Errorf("File %q already exists (use --force to overwrite it)", path)}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Unable to write %q", path)
+	}
+	// Permissions of an overwritten file are not changed by OpenFile
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "Unable to write %q", path)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "Unable to write %q", path)
+	}
+	return errors.Wrapf(file.Close(), "Unable to write %q", path)
+}
+
+func init() {
+	keygenCmd.Flags().StringP("algorithm", "a", string(license.RS256), "The signature algorithm the key is meant for (RS256, PS256, PS512, ES256, ES384 or EdDSA)")
+	keygenCmd.Flags().Int("bits", license.DefaultRSABits, "The size of RSA keys")
+	keygenCmd.Flags().String("private_key_out", "private.key", "The path of the generated private key")
+	keygenCmd.Flags().String("public_key_out", "public.key", "The path of the generated public key")
+	keygenCmd.Flags().String("passphrase", "", "The passphrase encrypting the private key: \"env:NAME\", \"file:PATH\", \"-\" (stdin) or the passphrase itself")
+	keygenCmd.Flags().Bool("force", false, "Overwrite existing key files")
+	rootCmd.AddCommand(keygenCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+// trustedKey is the public key embedded at build time (base64 encoded PEM or DER), empty if none
+var trustedKey string
+
+// Registering the flags selecting the keys used to verify licenses
+func addKeyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("public_key", "p", "", "The public key to use to validate the license (same forms as --license)")
+	cmd.Flags().String("jwks", "", "The JSON Web Key Set holding the keys to use to validate the license, selected by key ID (same forms as --license, exclusive with --public_key)")
+	cmd.Flags().Duration("key_cache_ttl", 24*time.Hour, "How long a public key fetched from an URL is cached on disk before being revalidated (0 disables the cache)")
+	cmd.Flags().String("key_cache_dir", "", "The directory of the public key cache (user cache directory by default)")
+	cmd.Flags().StringSlice("key_pin", nil, "The SHA-256 fingerprints the public key must match (required to trust a key which changed since it was cached)")
+	cmd.Flags().StringSlice("algorithms", nil, "The accepted signature algorithms, all by default (Eg: PS256,PS512,RS256)")
+	cmd.Flags().Bool("developer", false, "Allow --public_key and --jwks to override the trusted public key embedded at build time")
+}
+
+// Building the options shared by the commands loading licenses: access to BuyMint API and keys
+func licenseOptions() ([]license.Option, error) {
+	opts := []license.Option{
+		license.WithToken(viper.GetString("token")),
+		license.WithInsecureSkipVerify(viper.GetBool("self-signed")),
+		license.WithTimeout(commandTimeout()),
+	}
+	if algorithms := viper.GetStringSlice("algorithms"); len(algorithms) > 0 {
+		allowed := make([]license.Algorithm, 0, len(algorithms))
+		for _, algorithm := range algorithms {
+			allowed = append(allowed, license.Algorithm(algorithm))
+		}
+		opts = append(opts, license.WithAllowedAlgorithms(allowed...))
+	}
+	keyOpts, err := keyOptions()
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, keyOpts...), nil
+}
+
+// Building the options selecting the keys used to verify licenses
+// When a trusted key is embedded, it can only be overridden in developer mode
+func keyOptions() ([]license.Option, error) {
+	if viper.GetString("public_key") != "" && viper.GetString("jwks") != "" {
+		return nil, &exitError{code: ExitInvalidOption, err: errors.New("--public_key and --jwks are mutually exclusive")}
+	}
+	overridden := viper.GetString("public_key") != "" || viper.GetString("jwks") != ""
+	if trustedKey != "" && overridden && !viper.GetBool("developer") {
+		return nil, &exitError{code: ExitInvalidOption, err: errors.New("This build embeds its trusted public key: --public_key and --jwks require --developer")}
+	}
+	var opts []license.Option
+	if ttl := viper.GetDuration("key_cache_ttl"); ttl > 0 {
+		opts = append(opts, license.WithKeyCache(viper.GetString("key_cache_dir"), ttl))
+	}
+	if pins := viper.GetStringSlice("key_pin"); len(pins) > 0 {
+		opts = append(opts, license.WithKeyPin(pins...))
+	}
+	if trustedKey != "" && !overridden {
+		opts = append(opts, license.WithKeySource(license.FromEmbedded(trustedKey)))
+	}
+	if jwks := viper.GetString("jwks"); jwks != "" {
+		opts = append(opts, license.WithJWKS(argumentSource(jwks)))
+	}
+	if publicKey := viper.GetString("public_key"); publicKey != "" {
+		opts = append(opts, license.WithKeySource(argumentSource(publicKey)))
+	}
+	return opts, nil
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestKeyOptions(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("jwks", "https://buy.bmint.studio/.well-known/jwks.json")
+	if _, err := keyOptions(); err != nil {
+		t.Errorf("Expected --jwks alone to be accepted, got %v", err)
+	}
+	// The JWKS would silently win over the public key
+	viper.Set("public_key", "./public.key")
+	if _, err := keyOptions(); exitCode(err) != ExitInvalidOption {
+		t.Errorf("Expected ExitInvalidOption for --public_key with --jwks, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+// Loading the policy of the license: the file given by --policy or the "policy" section of the configuration,
+// completed by the metadata required by --meta
+func loadPolicy() (license.Policy, error) {
+	var policy license.Policy
+	switch value := viper.Get("policy").(type) {
+	case string:
+		if value != "" {
+			file := viper.New()
+			file.SetConfigFile(value)
+			if err := file.ReadInConfig(); err != nil {
+				return policy, &exitError{code: ExitInvalidOption, err: errors.Wrapf(err, "Unable to read policy %q", value)}
+			}
+			if err := file.Unmarshal(&policy); err != nil {
+				return policy, &exitError{code: ExitInvalidOption, err: errors.Wrapf(err, "Invalid policy %q", value)}
+			}
+		}
+	case nil:
+	default:
+		if err := viper.UnmarshalKey("policy", &policy); err != nil {
+			return policy, &exitError{code: ExitInvalidOption, err: errors.Wrap(err, "Invalid policy into configuration")}
+		}
+	}
+	expressions, keys, err := metaRequirements(viper.GetStringSlice("meta"))
+	if err != nil {
+		return policy, err
+	}
+	policy.Meta = append(policy.Meta, expressions...)
+	policy.MetaKeys = keys
+	// Reporting invalid requirements as usage errors
+	if _, err := policy.Requirements(); err != nil {
+		return policy, &exitError{code: ExitInvalidOption, err: err}
+	}
+	return policy, nil
+}
+
+// Parsing the --meta options: JSON objects of expected values (Eg: {"foo":"test"}) are equality requirements
+// on their literal keys, other values are requirement expressions (Eg: "seats>=10")
+func metaRequirements(values []string) ([]string, map[string]license.Requirement, error) {
+	var expressions []string
+	var keys map[string]license.Requirement
+	for _, value := range values {
+		if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+			expressions = append(expressions, value)
+			continue
+		}
+		var expected map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &expected); err != nil {
+			return nil, nil, &exitError{code: ExitInvalidOption, err: errors.Wrap(err, "Unable to parse meta from CLI argument")}
+		}
+		if keys == nil {
+			keys = make(map[string]license.Requirement, len(expected))
+		}
+		for key, expectedValue := range expected {
+			requirement := license.Equal(expectedValue)
+			if previous, found := keys[key]; found {
+				requirement = license.All(previous, requirement)
+			}
+			keys[key] = requirement
+		}
+	}
+	return expressions, keys, nil
+}
+
+// Description of the --meta option
+const metaUsage = `The meta data to validate (repeatable): a JSON object of expected values (Eg: {"foo":"test"}) or a requirement
+"path operator value" where path may go into nested metadata (Eg: customer.region) and operator is one of
+=, !=, >, >=, <, <= (numbers and dates), ~ (regular expression) or contains (Eg: "seats>=10", "features contains sso")`
+
+// Description of the --policy option
+const policyUsage = `The policy file (YAML or JSON) declaring the license requirements: meta, min_remaining, issuers, key_ids and algorithms
+(the "policy" section of the configuration file is used by default)`
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+func TestMetaRequirements(t *testing.T) {
+	expressions, keys, err := metaRequirements([]string{`{"my key": 1, "customer.region": "eu"}`, "seats>=10", `{"my key": 2}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expressions, []string{"seats>=10"}) {
+		t.Errorf("Expected only the non-JSON expressions, got %v", expressions)
+	}
+	expected := map[string]license.Requirement{
+		"my key":          license.All(license.Equal(1.0), license.Equal(2.0)),
+		"customer.region": license.Equal("eu"),
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected the JSON keys as literal keys %v, got %v", expected, keys)
+	}
+	if _, _, err := metaRequirements([]string{`{"agency": `}); err == nil {
+		t.Error("Expected an error for an invalid JSON object")
+	}
+}
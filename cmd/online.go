@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+// Registering the flags of the online verification
+func addOnlineFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("online", false, "Also check the live status of the license (active, suspended, refunded, subscription cancelled) against the licensor API")
+	cmd.Flags().String("status_url", license.DefaultStatusURL, "The licensor API endpoint of the license status, {serial} being replaced by the license serial")
+	cmd.Flags().Duration("offline_grace", 72*time.Hour, "How long the last online check is trusted when the licensor API is unreachable (0 requires the API)")
+	cmd.Flags().String("status_cache_dir", "", "The directory of the last online checks (user cache directory by default)")
+}
+
+// Building the options of the online verification (none without --online)
+func onlineOptions() []license.Option {
+	if !viper.GetBool("online") {
+		return nil
+	}
+	opts := []license.Option{license.WithOnlineCheck(viper.GetString("status_url"))}
+	if grace := viper.GetDuration("offline_grace"); grace > 0 {
+		opts = append(opts, license.WithOfflineGrace(viper.GetString("status_cache_dir"), grace))
+	}
+	return opts
+}
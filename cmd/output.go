@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of the command results (--output)
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+	OutputCSV  = "csv"
+)
+
+// Annotation of the commands supporting --output csv
+const annotationCSV = "output_csv"
+
+// DocumentVersion is the version of the JSON/YAML result documents, increased on every breaking change of their schema
+const DocumentVersion = 1
+
+// document is the result of a command written to stdout with --output json/yaml (documented into README.md)
+type document struct {
+	Version  int         `json:"version" yaml:"version"`
+	Command  string      `json:"command" yaml:"command"`
+	ExitCode int         `json:"exit_code" yaml:"exit_code"`
+	Error    string      `json:"error,omitempty" yaml:"error,omitempty"`
+	Result   interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+}
+
+// Result of the running command, written by Execute once the exit code is known
+var commandResult interface{}
+
+// Checking the --output option
+func checkOutput(cmd *cobra.Command, args []string) error {
+	switch viper.GetString("output") {
+	case OutputText, OutputJSON, OutputYAML:
+		return nil
+	case OutputCSV:
+		if cmd.Annotations[annotationCSV] == "true" {
+			return nil
+		}
+		return &exitError{code: ExitInvalidOption, err: errors.Errorf("Output csv is not supported by %s", cmd.Name())}
+	}
+	return &exitError{code: ExitInvalidOption, err: errors.Errorf("Invalid output %q (expected text, json, yaml or csv)", viper.GetString("output"))}
+}
+
+// Reporting the result of a command: printed right away in text format, kept for the result document otherwise
+func report(result interface{}, text func()) {
+	if viper.GetString("output") == OutputJSON || viper.GetString("output") == OutputYAML {
+		commandResult = result
+		return
+	}
+	text()
+}
+
+// Writing the result document of the executed command (nothing in text format)
+func writeDocument(out io.Writer, cmd *cobra.Command, err error) error {
+	format := viper.GetString("output")
+	if format != OutputJSON && format != OutputYAML {
+		return nil
+	}
+	// Help and version requests have no result
+	if commandResult == nil && err == nil {
+		return nil
+	}
+	// Subcommands are named by their path (Eg: "revocations sync")
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	doc := document{Version: DocumentVersion, Command: name, ExitCode: ExitOK, Result: commandResult}
+	if err != nil {
+		doc.ExitCode = exitCode(err)
+		doc.Error = err.Error()
+	}
+	if format == OutputYAML {
+		encoder := yaml.NewEncoder(out)
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(doc)
+}
+
+// Formatting a date for display (empty for a zero time)
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(time.RFC3339)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// failingWriter fails every write, like a closed pipe or a full disk
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteDocument(t *testing.T) {
+	defer viper.Reset()
+	defer func() { commandResult = nil }()
+	cmd := &cobra.Command{Use: "validate"}
+	commandResult = map[string]string{"serial": "A"}
+	for _, format := range []string{OutputJSON, OutputYAML} {
+		viper.Set("output", format)
+		var out bytes.Buffer
+		if err := writeDocument(&out, cmd, nil); err != nil || out.Len() == 0 {
+			t.Errorf("%s: expected the document to be written, got %q (%v)", format, out.String(), err)
+		}
+		if err := writeDocument(failingWriter{}, cmd, nil); err == nil {
+			t.Errorf("%s: expected the write error to be reported", format)
+		}
+	}
+	viper.Set("output", OutputJSON)
+	var out bytes.Buffer
+	if err := writeDocument(&out, cmd, &exitError{code: ExitInvalidOption, err: errors.New("Invalid output")}); err != nil {
+		t.Fatal(err)
+	}
+	var doc document
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil || doc.ExitCode != ExitInvalidOption || doc.Command != "validate" {
+		t.Errorf("Unexpected document %q (%v)", out.String(), err)
+	}
+}
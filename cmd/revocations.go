@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var revocationsCmd = &cobra.Command{
+	Use:   "revocations",
+	Short: "Manage the signed revocation list of licenses",
+}
+
+var revocationsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Fetch the revocation list right away and cache it, so that validations use it even offline",
+	Long: `Fetch the revocation list right away, whatever the TTL of its cache, verify its signature with the licensor keys
+and cache it, so that validations use it even offline.`,
+	PreRun: bindFlags,
+	RunE:   syncRevocations,
+}
+
+// syncedRevocations is the result document of revocations sync
+type syncedRevocations struct {
+	Source      string `json:"source" yaml:"source"`
+	IssuedOn    string `json:"issued_on" yaml:"issued_on"`
+	ExpiresOn   string `json:"expires_on" yaml:"expires_on"`
+	Revocations int    `json:"revocations" yaml:"revocations"`
+}
+
+func syncRevocations(cmd *cobra.Command, args []string) error {
+	if viper.GetString("revocation_list") == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing revocation list (--revocation_list)")}
+	}
+	if _, isURL := argumentSource(viper.GetString("revocation_list")).(*license.URLSource); !isURL {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Only a revocation list fetched from an URL is cached: nothing to sync")}
+	}
+	if viper.GetDuration("revocation_cache_ttl") <= 0 {
+		return &exitError{code: ExitInvalidOption, err: errors.New("The revocation cache is disabled (--revocation_cache_ttl 0): nothing to sync")}
+	}
+	opts, err := licenseOptions()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	ctx, cancel := commandContext()
+	defer cancel()
+	list, err := license.SyncRevocationList(ctx, append(opts, revocationOptions()...)...)
+	if err != nil {
+		return errors.Wrap(err, "Unable to sync revocation list")
+	}
+	result := syncedRevocations{
+		Source:      viper.GetString("revocation_list"),
+		IssuedOn:    formatDate(list.IssuedOn),
+		ExpiresOn:   formatDate(list.ExpiresOn),
+		Revocations: len(list.Revocations),
+	}
+	report(result, func() {
+		fmt.Fprintf(cmd.OutOrStdout(), "Revocation list %q synced (issued on %s, expires on %s, %d revoked licenses)\n", result.Source, result.IssuedOn, result.ExpiresOn, result.Revocations)
+	})
+	return nil
+}
+
+// Registering the flags selecting the revocation list checked by validations
+func addRevocationFlags(cmd *cobra.Command) {
+	cmd.Flags().String("revocation_list", "", "The revocation list signed by the licensor (same forms as --license); licenses are not checked for revocation if empty")
+	cmd.Flags().Duration("revocation_cache_ttl", time.Hour, "How long a revocation list fetched from an URL is cached on disk before being revalidated (0 disables the cache)")
+	cmd.Flags().String("revocation_cache_dir", "", "The directory of the revocation list cache (user cache directory by default)")
+	cmd.Flags().Duration("revocation_offline_grace", 72*time.Hour, "How long after its last fetch the cached revocation list is used when its URL is unreachable (0: until the list expires)")
+}
+
+// Building the options selecting the revocation list (none if --revocation_list is empty)
+func revocationOptions() []license.Option {
+	list := viper.GetString("revocation_list")
+	if list == "" {
+		return nil
+	}
+	opts := []license.Option{license.WithRevocationSource(argumentSource(list))}
+	if ttl := viper.GetDuration("revocation_cache_ttl"); ttl > 0 {
+		opts = append(opts, license.WithRevocationCache(viper.GetString("revocation_cache_dir"), ttl))
+	}
+	if grace := viper.GetDuration("revocation_offline_grace"); grace > 0 {
+		opts = append(opts, license.WithRevocationOfflineGrace(grace))
+	}
+	return opts
+}
+
+func init() {
+	addKeyFlags(revocationsSyncCmd)
+	addRevocationFlags(revocationsSyncCmd)
+	revocationsCmd.AddCommand(revocationsSyncCmd)
+	rootCmd.AddCommand(revocationsCmd)
+}
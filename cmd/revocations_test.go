@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSyncRevocationsSource(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("revocation_cache_ttl", "1h")
+	// Only the lists fetched from an URL are cached
+	for _, list := range []string{"./revocations.json", "file:revocations.json", "env:REVOCATIONS", `{"list": "{}"}`} {
+		viper.Set("revocation_list", list)
+		if err := syncRevocations(revocationsSyncCmd, nil); exitCode(err) != ExitInvalidOption {
+			t.Errorf("%s: expected ExitInvalidOption, got %v", list, err)
+		}
+	}
+}
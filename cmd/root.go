@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/rest"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -13,20 +17,63 @@ import (
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "buymint-cli",
-	Short: "buymint-cli - CLI to access and use BuyMint API",
-	Long:  `buymint-cli - command line interface to access and use BuyMint API`,
+	Use:               "buymint-cli",
+	Short:             "buymint-cli - CLI to access and use BuyMint API",
+	Long:              `buymint-cli - command line interface to access and use BuyMint API`,
+	PersistentPreRunE: checkOptions,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately. This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute(v string, bh string, bd string) {
+func Execute(v string, bh string, bd string, tk string) {
 	rootCmd.Version = v + " (Build: " + bd + ")"
+	trustedKey = tk
+	// Errors are printed here in order to return the proper exit code
+	rootCmd.SilenceErrors = true
 	// Executing...
-	if err := rootCmd.Execute(); err != nil {
-		logger.Fatal(errors.Wrap(err, "Failed to execute command").Error())
+	cmd, err := rootCmd.ExecuteC()
+	if docErr := writeDocument(rootCmd.OutOrStdout(), cmd, err); docErr != nil {
+		// A truncated document must not pass for a successful result
+		logger.Error(docErr, "Failed to write result document")
+		rootCmd.PrintErrln("Error: Unable to write the result document:", docErr.Error())
+		if err == nil {
+			os.Exit(ExitFailure)
+		}
+	}
+	if err != nil {
+		if err.Error() != "" {
+			logger.Error(err, "Failed to execute command")
+			rootCmd.PrintErrln("Error:", err.Error())
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
+// Checking the global options
+func checkOptions(cmd *cobra.Command, args []string) error {
+	if viper.GetDuration("timeout") < 0 {
+		return &exitError{code: ExitInvalidOption, err: errors.Errorf("Invalid timeout %s (expected a positive duration or 0 for the default one)", viper.GetDuration("timeout"))}
+	}
+	return checkOutput(cmd, args)
+}
+
+// Reading the --timeout option, 0 meaning the default timeout
+func commandTimeout() time.Duration {
+	if timeout := viper.GetDuration("timeout"); timeout > 0 {
+		return timeout
+	}
+	return rest.DefaultTimeout
+}
+
+// Building the context of a command, bounded by the --timeout option
+func commandContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), commandTimeout())
+}
+
+// Binding the flags of the running command only, as several commands share flag names
+func bindFlags(cmd *cobra.Command, args []string) {
+	viper.BindPFlags(cmd.Flags())
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "config.json", "Configuration file to use")
 	viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
@@ -44,6 +91,10 @@ func init() {
 	viper.BindPFlag("self-signed", rootCmd.PersistentFlags().Lookup("self-signed"))
 	rootCmd.PersistentFlags().StringP("token", "t", "", `Authentication token to contact BuyMint API`)
 	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
+	rootCmd.PersistentFlags().Duration("timeout", rest.DefaultTimeout, `Time limit to contact BuyMint API (Eg: 10s), 0 for the default one`)
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	rootCmd.PersistentFlags().String("output", OutputText, `Format of the command result written to stdout: text, json, yaml or csv (audit only) (logs are written to stderr)`)
+	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 
 	cobra.OnInitialize(func() {
 		// Reading custom config file (if set) and merging content with default config file content
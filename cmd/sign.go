@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+var signCmd = &cobra.Command{
+	Use:    "sign",
+	Short:  "Issue a license signed with a licensor private key",
+	PreRun: bindFlags,
+	RunE:   signLicense,
+}
+
+// signedLicense is the result document of sign
+type signedLicense struct {
+	Serial  string `json:"serial" yaml:"serial"`
+	KeyID   string `json:"key_id,omitempty" yaml:"key_id,omitempty"`
+	Out     string `json:"out,omitempty" yaml:"out,omitempty"`
+	License string `json:"license" yaml:"license"`
+}
+
+func signLicense(cmd *cobra.Command, args []string) error {
+	if viper.GetString("private_key") == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing private key (--private_key)")}
+	}
+	if viper.GetString("serial") == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing serial (--serial)")}
+	}
+	// Building the license template from the options
+	template := license.License{
+		Serial:         viper.GetString("serial"),
+		TransactionID:  viper.GetString("transaction_id"),
+		SubscriptionID: viper.GetString("subscription_id"),
+		IssuedFor:      viper.GetString("issued_for"),
+		Algorithm:      license.Algorithm(viper.GetString("algorithm")),
+		KeyID:          viper.GetString("key_id"),
+	}
+	var err error
+	if template.LicensedTo, err = license.ParseIdentity(viper.GetString("licensed_to")); err != nil {
+		return &exitError{code: ExitInvalidOption, err: errors.Wrap(err, "Invalid --licensed_to")}
+	}
+	if template.IssuedBy, err = license.ParseIdentity(viper.GetString("issued_by")); err != nil {
+		return &exitError{code: ExitInvalidOption, err: errors.Wrap(err, "Invalid --issued_by")}
+	}
+	if err := json.Unmarshal([]byte(viper.GetString("meta")), &template.Meta); err != nil {
+		return &exitError{code: ExitInvalidOption, err: errors.Wrap(err, "Unable to parse meta from CLI argument")}
+	}
+	// Binding the license to the machines of the customer (node-locked license)
+	if fingerprints := viper.GetStringSlice("fingerprint"); len(fingerprints) > 0 {
+		if _, found := template.Meta[license.MetaFingerprint]; found {
+			return &exitError{code: ExitInvalidOption, err: errors.New("Fingerprint given by both --fingerprint and --meta")}
+		}
+		if template.Meta == nil {
+			template.Meta = map[string]interface{}{}
+		}
+		if len(fingerprints) == 1 {
+			template.Meta[license.MetaFingerprint] = fingerprints[0]
+		} else {
+			template.Meta[license.MetaFingerprint] = fingerprints
+		}
+	}
+	template.SignedOn = time.Now().UTC()
+	if template.ExpiresOn, err = parseExpiry(viper.GetString("expires_on"), template.SignedOn); err != nil {
+		return &exitError{code: ExitInvalidOption, err: err}
+	}
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	// Loading the private key
+	ctx, cancel := commandContext()
+	defer cancel()
+	privateKey, err := argumentSource(viper.GetString("private_key")).ReadKey(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Unable to read private key")
+	}
+	key, err := license.ParsePrivateKey(privateKey, passphrase)
+	if err != nil {
+		return err
+	}
+	// "auto" derives the key ID from the key, the same way keygen prints it
+	if template.KeyID == "auto" {
+		if template.KeyID, err = license.KeyID(key.Public()); err != nil {
+			return err
+		}
+	}
+	issued, err := license.Issue(template, key)
+	if err != nil {
+		return err
+	}
+	result := signedLicense{Serial: template.Serial, KeyID: template.KeyID, License: issued}
+	if out := viper.GetString("out"); out != "-" {
+		if err := os.WriteFile(out, []byte(issued), 0644); err != nil {
+			return errors.Wrapf(err, "Unable to write %q", out)
+		}
+		result.Out = out
+	}
+	report(result, func() {
+		if result.Out == "" {
+			fmt.Fprint(cmd.OutOrStdout(), result.License)
+		}
+	})
+	return nil
+}
+
+// Parsing the expiration date: a RFC 3339 date, a duration from now (Eg: 8760h) or empty for a perpetual license
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(duration), nil
+	}
+	expiresOn, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("Invalid --expires_on %q (expected a RFC 3339 date or a duration)", value)
+	}
+	return expiresOn, nil
+}
+
+func init() {
+	signCmd.Flags().StringP("private_key", "k", "", "The licensor private key (same forms as --license of validate)")
+	signCmd.Flags().String("passphrase", "", "The passphrase decrypting the private key: \"env:NAME\", \"file:PATH\", \"-\" (stdin) or the passphrase itself")
+	signCmd.Flags().StringP("serial", "s", "", "The serial of the license")
+	signCmd.Flags().String("licensed_to", "", "The licensee (Eg: 'Foo Inc (organization: \"license@foo.test\")')")
+	signCmd.Flags().StringP("meta", "m", "{}", "The meta data of the license, written in JSON format (Eg: {\"foo\":\"test\"})")
+	signCmd.Flags().StringSlice("fingerprint", nil, "The fingerprints of the machines the license is bound to (printed by buymint-cli fingerprint)")
+	signCmd.Flags().StringP("expires_on", "e", "", "The expiration date: a RFC 3339 date or a duration from now (Eg: 8760h); perpetual if empty")
+	signCmd.Flags().String("issued_by", "", "The issuer (Eg: 'Foo Test (user: \"foo@test.cloud\")')")
+	signCmd.Flags().String("issued_for", "", "What the license is issued for")
+	signCmd.Flags().String("transaction_id", "", "The transaction ID of the license")
+	signCmd.Flags().String("subscription_id", "", "The subscription ID of the license")
+	signCmd.Flags().StringP("algorithm", "a", "", "The signature algorithm (the one of the key by default: RS256 for RSA keys)")
+	signCmd.Flags().String("key_id", "", "The key ID written into the license (\"auto\" derives it from the private key)")
+	signCmd.Flags().StringP("out", "o", "-", "Where to write the license (\"-\" for stdout)")
+	rootCmd.AddCommand(signCmd)
+}
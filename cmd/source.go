@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	license "github.com/Clevermind-Think-Mint/buymint-cli-go/pkg"
+)
+
+// Building the source of a license/key CLI argument:
+// "-" reads from stdin, "env:NAME" from an environment variable, "file:PATH" from a file,
+// otherwise the argument is guessed as an URL, a path or the content itself
+func argumentSource(arg string) license.Source {
+	switch {
+	case arg == "-":
+		return license.FromReader(os.Stdin)
+	case strings.HasPrefix(arg, "env:"):
+		return license.FromEnv(strings.TrimPrefix(arg, "env:"))
+	case strings.HasPrefix(arg, "file:"):
+		return license.FromFile(strings.TrimPrefix(arg, "file:"))
+	}
+	return license.GuessSource(arg)
+}
+
+// Reading the passphrase protecting the private key (empty if none), given with the same forms as --license
+func readPassphrase() ([]byte, error) {
+	value := viper.GetString("passphrase")
+	if value == "" {
+		return nil, nil
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	passphrase, err := argumentSource(value).ReadKey(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read passphrase")
+	}
+	return []byte(strings.TrimRight(string(passphrase), "\r\n")), nil
+}
+
+// Reading the content of a license CLI argument, contacting BuyMint API with the global options
+func readArgument(ctx context.Context, arg string) ([]byte, error) {
+	source := argumentSource(arg)
+	if urlSource, ok := source.(*license.URLSource); ok {
+		urlSource.Token = viper.GetString("token")
+		urlSource.InsecureSkipVerify = viper.GetBool("self-signed")
+		urlSource.Timeout = commandTimeout()
+	}
+	return source.ReadLicense(ctx)
+}
@@ -1,7 +1,8 @@
 package cmd
 
 import (
-	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -11,41 +12,154 @@ import (
 )
 
 var validateLicenseCmd = &cobra.Command{
-	Use:   "validate",
-	Short: "Validate a license against a specific serial/metas",
-	RunE:  validateLicense,
+	Use:    "validate",
+	Short:  "Validate a license against a specific serial/metas",
+	PreRun: bindFlags,
+	RunE:   validateLicense,
 }
 
 func validateLicense(cmd *cobra.Command, args []string) error {
-	// Parsing meta JSON string
-	metaToParse := viper.GetString("meta")
-	var meta map[string]interface{}
-	if err := json.Unmarshal([]byte(metaToParse), &meta); err != nil {
-		return errors.Wrap(err, "Unable to parse meta from CLI argument")
+	if viper.GetString("license") == "" {
+		return &exitError{code: ExitInvalidOption, err: errors.New("Missing license (--license)")}
+	}
+	// Loading the requirements of the license
+	policy, err := loadPolicy()
+	if err != nil {
+		return err
 	}
 	// Building new license
-	license, err := license.New(viper.GetString("license"), map[string]interface{}{
-		"PublicKey":         viper.GetString("public_key"),
-		"IgnoreInsecureSsl": viper.GetBool("self-signed"),
-		"Token":             viper.GetString("token"),
-	})
+	opts, err := licenseOptions()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, revocationOptions()...)
+	opts = append(opts, onlineOptions()...)
+	opts = append(opts, activationOptions()...)
+	fingerprintOpts, _, err := fingerprintOptions()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, license.WithFingerprint(fingerprintOpts...))
+	opts = append(opts,
+		license.WithGracePeriod(viper.GetDuration("grace_period")),
+		license.WithWarningPeriod(viper.GetDuration("warning_period")),
+	)
+	// From now on errors are related to the license itself, not to the command usage
+	cmd.SilenceUsage = true
+	ctx, cancel := commandContext()
+	defer cancel()
+	license, err := license.NewFromSource(ctx, argumentSource(viper.GetString("license")), opts...)
 	if err != nil {
 		return errors.Wrap(err, "Unable to initialize License")
 	}
 	// Validating license against desired data
-	_, err = license.Validate(meta)
+	result, err := license.ValidateWithPolicy(policy)
+	report(newValidation(license.Serial, result), func() {
+		if err != nil {
+			// Reporting every failed check, not only the first one
+			for _, check := range result.Failed() {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Check %q failed: %s\n", check.Name, check.Reason)
+			}
+			return
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), describeValidity(license.Serial, result.Validity))
+	})
 	if err != nil {
-		return err
+		return &exitError{code: exitCode(err)}
+	}
+	// Reporting the validity status (warnings have their own exit code)
+	if code := statusExitCode(result.Validity.Status); code != ExitOK {
+		return &exitError{code: code}
 	}
 	return nil
 }
 
+// validation is the result document of validate
+type validation struct {
+	Serial                string          `json:"serial" yaml:"serial"`
+	Valid                 bool            `json:"valid" yaml:"valid"`
+	Status                license.Status  `json:"status" yaml:"status"`
+	ExpiresOn             string          `json:"expires_on" yaml:"expires_on"`
+	RemainingSeconds      int64           `json:"remaining_seconds" yaml:"remaining_seconds"`
+	GraceRemainingSeconds int64           `json:"grace_remaining_seconds" yaml:"grace_remaining_seconds"`
+	Checks                []checkDocument `json:"checks" yaml:"checks"`
+	Online                *onlineDocument `json:"online,omitempty" yaml:"online,omitempty"`
+	Activation            *activation     `json:"activation,omitempty" yaml:"activation,omitempty"`
+}
+
+// onlineDocument is the online verification of the validation result document
+type onlineDocument struct {
+	Status    license.OnlineStatus `json:"status" yaml:"status"`
+	CheckedOn string               `json:"checked_on" yaml:"checked_on"`
+	Offline   bool                 `json:"offline" yaml:"offline"`
+}
+
+// checkDocument is a check of the validation result document
+type checkDocument struct {
+	Name     string      `json:"name" yaml:"name"`
+	Passed   bool        `json:"passed" yaml:"passed"`
+	Reason   string      `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Expected interface{} `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty" yaml:"actual,omitempty"`
+}
+
+// Building the validation result document
+func newValidation(serial string, result *license.ValidationResult) validation {
+	doc := validation{
+		Serial:                serial,
+		Valid:                 result.Valid,
+		Status:                result.Validity.Status,
+		ExpiresOn:             formatDate(result.Validity.ExpiresOn),
+		RemainingSeconds:      int64(result.Validity.Remaining / time.Second),
+		GraceRemainingSeconds: int64(result.Validity.GraceRemaining / time.Second),
+		Checks:                make([]checkDocument, 0, len(result.Checks)),
+	}
+	if result.Online != nil {
+		doc.Online = &onlineDocument{Status: result.Online.Status, CheckedOn: formatDate(result.Online.CheckedOn), Offline: result.Online.Offline}
+	}
+	if result.Activation != nil {
+		doc.Activation = newActivation(result.Activation)
+	}
+	for _, check := range result.Checks {
+		doc.Checks = append(doc.Checks, checkDocument{Name: check.Name, Passed: check.Passed, Reason: check.Reason, Expected: check.Expected, Actual: check.Actual})
+	}
+	return doc
+}
+
+// Describing the validity status in a human friendly way
+func describeValidity(serial string, validity license.Validity) string {
+	switch validity.Status {
+	case license.StatusExpiringSoon:
+		return fmt.Sprintf("License %q is valid but expires soon (on %s, %s left)", serial, validity.ExpiresOn.Format(time.RFC3339), formatDuration(validity.Remaining))
+	case license.StatusInGrace:
+		return fmt.Sprintf("License %q expired on %s and is in grace period (%s left)", serial, validity.ExpiresOn.Format(time.RFC3339), formatDuration(validity.GraceRemaining))
+	}
+	if validity.ExpiresOn.IsZero() {
+		return fmt.Sprintf("License %q is valid (perpetual)", serial)
+	}
+	return fmt.Sprintf("License %q is valid (expires on %s, %s left)", serial, validity.ExpiresOn.Format(time.RFC3339), formatDuration(validity.Remaining))
+}
+
+// Formatting a duration in days, hours and minutes
+func formatDuration(duration time.Duration) string {
+	duration = duration.Round(time.Minute)
+	days := duration / (24 * time.Hour)
+	duration -= days * 24 * time.Hour
+	hours := duration / time.Hour
+	duration -= hours * time.Hour
+	return fmt.Sprintf("%dd %dh %dm", days, hours, duration/time.Minute)
+}
+
 func init() {
-	validateLicenseCmd.Flags().StringP("license", "l", "", "The license")
-	viper.BindPFlag("license", validateLicenseCmd.Flags().Lookup("license"))
-	validateLicenseCmd.Flags().StringP("meta", "m", "{}", "The meta data to validate, written in JSON format (Eg: {\"foo\":\"test\"})")
-	viper.BindPFlag("meta", validateLicenseCmd.Flags().Lookup("meta"))
-	validateLicenseCmd.Flags().StringP("public_key", "p", "", "The public key to use to validate the license")
-	viper.BindPFlag("public_key", validateLicenseCmd.Flags().Lookup("public_key"))
+	validateLicenseCmd.Flags().StringP("license", "l", "", "The license: an URL, a path, the content itself, \"-\" (stdin), \"env:NAME\" or \"file:PATH\"")
+	validateLicenseCmd.Flags().StringArrayP("meta", "m", nil, metaUsage)
+	validateLicenseCmd.Flags().String("policy", "", policyUsage)
+	addKeyFlags(validateLicenseCmd)
+	addRevocationFlags(validateLicenseCmd)
+	addOnlineFlags(validateLicenseCmd)
+	addFingerprintFlags(validateLicenseCmd)
+	addActivationFlags(validateLicenseCmd)
+	validateLicenseCmd.Flags().Duration("grace_period", 0, "How long an expired license is still accepted (with a warning) after its expiration (Eg: 168h)")
+	validateLicenseCmd.Flags().Duration("warning_period", 0, "How long before its expiration a license is reported as expiring soon (Eg: 720h)")
 	rootCmd.AddCommand(validateLicenseCmd)
 }
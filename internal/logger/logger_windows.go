@@ -10,6 +10,6 @@ import (
 
 // Used to initialize human-freindly, colorize output
 func logPrettyInit() error {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: colorable.NewColorableStdout(), TimeFormat: time.RFC3339})
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: colorable.NewColorableStderr(), TimeFormat: time.RFC3339})
 	return nil
 }
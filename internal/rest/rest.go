@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
 	//cookiejar "github.com/juju/persistent-cookiejar"
@@ -33,18 +34,31 @@ func (customPSL) PublicSuffix(d string) string {
 	return d[strings.LastIndex(d, ".")+1:]
 }
 
+// DefaultTimeout is the time limit of a request (dial, TLS handshake and body read included) when no "Timeout" option is set
+const DefaultTimeout = 30 * time.Second
+
 // Get is...
 func Get(URL string, headers map[string]string, options map[string]interface{}) (int, []byte, map[string]string, error) {
+	return GetWithContext(context.Background(), URL, headers, options)
+}
+
+// GetWithContext is Get with a context carrying cancellation and deadline of the request
+func GetWithContext(ctx context.Context, URL string, headers map[string]string, options map[string]interface{}) (int, []byte, map[string]string, error) {
 	// Init headers if needed
 	if headers == nil {
 		headers = make(map[string]string, 0)
 	}
 	// Fetching
-	return fetch(http.MethodGet, URL, headers, nil, options)
+	return fetch(ctx, http.MethodGet, URL, headers, nil, options)
 }
 
 // Post is...
 func Post(URL string, data interface{}, headers map[string]string, options map[string]interface{}) (int, []byte, map[string]string, error) {
+	return PostWithContext(context.Background(), URL, data, headers, options)
+}
+
+// PostWithContext is Post with a context carrying cancellation and deadline of the request
+func PostWithContext(ctx context.Context, URL string, data interface{}, headers map[string]string, options map[string]interface{}) (int, []byte, map[string]string, error) {
 	// Init options if needed
 	if options == nil {
 		options = make(map[string]interface{}, 0)
@@ -76,10 +90,10 @@ func Post(URL string, data interface{}, headers map[string]string, options map[s
 		}
 	}
 	// Fetching
-	return fetch(http.MethodPost, URL, headers, bodyRequest, options)
+	return fetch(ctx, http.MethodPost, URL, headers, bodyRequest, options)
 }
 
-func fetch(method string, URL string, headers map[string]string, bodyRequest io.Reader, options map[string]interface{}) (int, []byte, map[string]string, error) {
+func fetch(ctx context.Context, method string, URL string, headers map[string]string, bodyRequest io.Reader, options map[string]interface{}) (int, []byte, map[string]string, error) {
 	if options == nil {
 		options = make(map[string]interface{}, 0)
 	}
@@ -89,9 +103,12 @@ func fetch(method string, URL string, headers map[string]string, bodyRequest io.
 	if options["IgnoreInsecureSsl"] == nil {
 		options["IgnoreInsecureSsl"] = false
 	}
+	if options["Timeout"] == nil {
+		options["Timeout"] = DefaultTimeout
+	}
 
 	// Building request
-	request, err := http.NewRequest(method, URL, bodyRequest)
+	request, err := http.NewRequestWithContext(ctx, method, URL, bodyRequest)
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -112,25 +129,32 @@ func fetch(method string, URL string, headers map[string]string, bodyRequest io.
 		}
 	}
 	originDomain := ""
-	response, err := (&http.Client{
-		Jar: jar,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: options["IgnoreInsecureSsl"].(bool)},
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				conn, err := net.Dial(network, addr)
-				if err == nil {
-					originDomain = conn.LocalAddr().String()
+	// Using the client offered by the caller (if any)
+	httpClient, _ := options["HTTPClient"].(*http.Client)
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Jar:     jar,
+			Timeout: options["Timeout"].(time.Duration),
+			Transport: &http.Transport{
+				TLSClientConfig:     &tls.Config{InsecureSkipVerify: options["IgnoreInsecureSsl"].(bool)},
+				TLSHandshakeTimeout: options["Timeout"].(time.Duration),
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+					if err == nil {
+						originDomain = conn.LocalAddr().String()
+					}
+					return conn, err
+				},
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if !options["redirect"].(bool) {
+					return http.ErrUseLastResponse
 				}
-				return conn, err
+				return nil
 			},
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if !options["redirect"].(bool) {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}).Do(request)
+		}
+	}
+	response, err := httpClient.Do(request)
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -9,8 +9,10 @@ var (
 	buildHash = "No Git-hash Provided."
 	// buildDate of application at compile time (-X 'main.buildDate=$(BUILDDATE)').
 	buildDate = "No Build Date Provided."
+	// trustedKey public key of application at compile time, base64 encoded (-X 'main.trustedKey=$(TRUSTEDKEY)').
+	trustedKey = ""
 )
 
 func main() {
-	cmd.Execute(version, buildHash, buildDate)
+	cmd.Execute(version, buildHash, buildDate, trustedKey)
 }
@@ -0,0 +1,296 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/pkg/fingerprint"
+	"github.com/pkg/errors"
+)
+
+// DefaultActivationURL is the BuyMint API endpoint of the activations ("/activate" and "/deactivate" are appended)
+const DefaultActivationURL = "https://buy.bmint.studio/api/v1/service/microservice/licensor/activation"
+
+// MetaMaxActivations is the metadata of seat-limited licenses: the number of machines the license can be activated on
+// at the same time; Validate requires such a license to be activated on the current machine (see Activate)
+const MetaMaxActivations = "max_activations"
+
+// Activation is the seat taken by a license on a machine, signed by the licensor (see IssueActivation)
+type Activation struct {
+	Serial      string    `json:"serial"`
+	Fingerprint string    `json:"fingerprint"`
+	ActivatedOn time.Time `json:"activated_on"`
+	// ExpiresOn ends the activation so that the seats of lost machines are released (zero until Deactivate)
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+// activationEnvelope is the signed form of an activation (the activation token): the activation is kept as the JSON text which was signed
+type activationEnvelope struct {
+	Activation string    `json:"activation"`
+	Algorithm  Algorithm `json:"algorithm"`
+	KeyID      string    `json:"key_id,omitempty"`
+	Signature  string    `json:"signature"`
+}
+
+// Checking the activation is the one of the license on this machine and is not expired
+func (a *Activation) check(serial string, host string, now time.Time) error {
+	if a.Serial != serial {
+		return newError(ErrNotActivated, nil, "Activation token is issued for license %q, not %q", a.Serial, serial)
+	}
+	if !fingerprint.Equal(a.Fingerprint, host) {
+		return newError(ErrNotActivated, nil, "License %q is activated on another machine (%s), not on this one (%s)", serial, a.Fingerprint, host)
+	}
+	if !a.ExpiresOn.IsZero() && now.After(a.ExpiresOn) {
+		return newError(ErrNotActivated, nil, "Activation of license %q expired on %s", serial, a.ExpiresOn.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// IssueActivation signs the activation with the licensor private key (with the algorithm of the key,
+// RS256 for RSA keys); keyID selects the key into the keyring of the readers (Eg: KeyID of the key, or empty)
+func IssueActivation(activation Activation, key crypto.Signer, keyID string) ([]byte, error) {
+	if key == nil {
+		return nil, newError(ErrInvalidKey, nil, "Missing private key")
+	}
+	if activation.Serial == "" || activation.Fingerprint == "" {
+		return nil, newError(ErrInvalidOption, nil, "Activation requires a serial and a fingerprint")
+	}
+	if activation.ActivatedOn.IsZero() {
+		activation.ActivatedOn = time.Now().UTC()
+	}
+	content, err := json.Marshal(activation)
+	if err != nil {
+		return nil, newError(ErrInvalidOption, err, "Unable to encode activation")
+	}
+	algorithm, signature, err := signPayload(key, content)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(activationEnvelope{
+		Activation: string(content),
+		Algorithm:  algorithm,
+		KeyID:      keyID,
+		Signature:  signature,
+	}, "", "  ")
+}
+
+// ParseActivation verifies the activation token with the trusted keys and reads it
+func ParseActivation(token []byte, keyring *Keyring) (*Activation, error) {
+	return parseActivation(token, keyring, &options{clock: time.Now})
+}
+
+// Verifying the signature of the activation token with the key selected by its key ID, then reading it
+func parseActivation(token []byte, keyring *Keyring, o *options) (*Activation, error) {
+	var envelope activationEnvelope
+	if err := json.Unmarshal(token, &envelope); err != nil {
+		return nil, newError(ErrInvalidFormat, err, "Unable to parse activation token")
+	}
+	if err := verifyPayload([]byte(envelope.Activation), envelope.Algorithm, envelope.KeyID, envelope.Signature, keyring, o); err != nil {
+		return nil, errors.Wrap(err, "Unable to verify activation token")
+	}
+	var activation Activation
+	if err := json.Unmarshal([]byte(envelope.Activation), &activation); err != nil {
+		return nil, newError(ErrInvalidFormat, err, "Unable to parse activation token")
+	}
+	return &activation, nil
+}
+
+// Activate takes a seat of the license on the current machine: the licensor API (WithActivationURL) is sent the serial
+// and the fingerprint of the machine, and answers with a signed activation token, verified with the trusted keys and
+// stored (WithActivationStore) for Validate
+func Activate(ctx context.Context, serial string, opts ...Option) (*Activation, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if serial == "" {
+		return nil, newError(ErrInvalidOption, nil, "Empty serial")
+	}
+	store, err := o.activations()
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := loadKeyring(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	host, err := hostFingerprint(o)
+	if err != nil {
+		return nil, err
+	}
+	_, token, err := postActivation(ctx, o, "activate", serial, map[string]interface{}{"serial": serial, "fingerprint": host})
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Verifying activation token:\n\n%s", token)
+	activation, err := parseActivation(token, keyring, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := activation.check(serial, host, o.clock()); err != nil {
+		return nil, errors.Wrap(err, "Unexpected activation token")
+	}
+	if err := store.write(serial, token); err != nil {
+		return nil, errors.Wrap(err, "Unable to store activation token")
+	}
+	return activation, nil
+}
+
+// Deactivate releases the seat taken by the license on the current machine: the licensor API is sent the stored
+// activation token, which is removed once the API accepted it. A token unknown to the API (404, Eg: the seat was
+// released by the licensor) is stale and removed as well
+func Deactivate(ctx context.Context, serial string, opts ...Option) error {
+	o, err := newOptions(opts)
+	if err != nil {
+		return err
+	}
+	if serial == "" {
+		return newError(ErrInvalidOption, nil, "Empty serial")
+	}
+	store, err := o.activations()
+	if err != nil {
+		return err
+	}
+	token, err := store.read(serial)
+	if err != nil {
+		return err
+	}
+	host, err := hostFingerprint(o)
+	if err != nil {
+		return err
+	}
+	status, _, err := postActivation(ctx, o, "deactivate", serial, map[string]interface{}{"serial": serial, "fingerprint": host, "token": string(token)})
+	switch {
+	case status == http.StatusNotFound:
+		logger.Warn("%s, removing the stale activation token", err)
+	case err != nil:
+		return err
+	}
+	if err := store.remove(serial); err != nil {
+		return errors.Wrap(err, "Unable to remove activation token")
+	}
+	return nil
+}
+
+// Posting to the activation API; a refusal (Eg: no seat left, unknown activation) is reported with the reason given by the API
+// along with the status of the response
+func postActivation(ctx context.Context, o *options, action string, serial string, data map[string]interface{}) (int, []byte, error) {
+	URL := strings.TrimSuffix(o.activationURL, "/") + "/" + action
+	status, content, err := FromURL(URL).withOptions(o).post(ctx, data)
+	switch {
+	case err == nil:
+		return status, content, nil
+	case status == http.StatusNotFound || status == http.StatusConflict:
+		return status, nil, newError(ErrNotActivated, nil, "Licensor API refused to %s license %q: %s", action, serial, refusalReason(status, content))
+	}
+	return status, nil, err
+}
+
+// Reading the reason of a refusal from the response of the API ({"message": "..."} or plain text)
+func refusalReason(status int, content []byte) string {
+	var response struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(content, &response); err == nil {
+		if response.Message != "" {
+			return response.Message
+		}
+		if response.Error != "" {
+			return response.Error
+		}
+	}
+	if reason := strings.TrimSpace(string(content)); reason != "" && !strings.HasPrefix(reason, "{") {
+		return reason
+	}
+	return http.StatusText(status)
+}
+
+// Reading and verifying the activation of the license on this machine
+func loadActivation(o *options, keyring *Keyring, serial string) (*Activation, error) {
+	store, err := o.activations()
+	if err != nil {
+		return nil, err
+	}
+	token, err := store.read(serial)
+	if err != nil {
+		return nil, err
+	}
+	return parseActivation(token, keyring, o)
+}
+
+// Checking the seat-limited license is activated on this machine
+func (t *License) checkActivation() error {
+	if t.activationErr != nil {
+		return t.activationErr
+	}
+	if t.fingerprintErr != nil {
+		return t.fingerprintErr
+	}
+	return t.activation.check(t.Serial, t.hostFingerprint, t.now())
+}
+
+// DefaultActivationDir is the directory where the activation tokens are stored (under the user config directory,
+// since they can't be fetched again without taking another seat)
+func DefaultActivationDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buymint-cli", "activations"), nil
+}
+
+// activationStore keeps the activation token of every license activated on this machine
+type activationStore struct {
+	dir string
+}
+
+// activationStoreEntry is the content of a store file
+type activationStoreEntry struct {
+	Serial string `json:"serial"`
+	Token  string `json:"token"`
+}
+
+// Path of the store file of a license
+func (s *activationStore) path(serial string) string {
+	sum := sha256.Sum256([]byte(serial))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Reading the activation token of a license (ErrNotActivated if missing)
+func (s *activationStore) read(serial string) ([]byte, error) {
+	content, err := os.ReadFile(s.path(serial))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, newError(ErrNotActivated, nil, "License %q is not activated on this machine", serial)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read activation token")
+	}
+	var entry activationStoreEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.Serial != serial {
+		return nil, newError(ErrInvalidFormat, err, "Corrupted activation token of license %q", serial)
+	}
+	return []byte(entry.Token), nil
+}
+
+// Writing the activation token of a license
+func (s *activationStore) write(serial string, token []byte) error {
+	return writeCacheFile(s.dir, s.path(serial), &activationStoreEntry{Serial: serial, Token: string(token)})
+}
+
+// Removing the activation token of a license
+func (s *activationStore) remove(serial string) error {
+	if err := os.Remove(s.path(serial)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package license
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestActivation(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	activatedOn := signedOn.AddDate(0, 1, 0)
+	now := activatedOn
+	keyring, err := NewKeyring(TrustedKey{Key: key.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Licensor API stand-in offering a single seat per license
+	var mutex sync.Mutex
+	seats := map[string]string{}
+	token := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		token = r.Header.Get("Authorization")
+		var request struct {
+			Serial      string `json:"serial"`
+			Fingerprint string `json:"fingerprint"`
+			Token       string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/activation/activate":
+			if holder, taken := seats[request.Serial]; taken && holder != request.Fingerprint {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"message": "No seat left"}`))
+				return
+			}
+			seats[request.Serial] = request.Fingerprint
+			content, err := IssueActivation(Activation{Serial: request.Serial, Fingerprint: request.Fingerprint, ActivatedOn: activatedOn, ExpiresOn: activatedOn.AddDate(0, 1, 0)}, key, "")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Write(content)
+		case "/activation/deactivate":
+			activation, err := ParseActivation([]byte(request.Token), keyring)
+			if err != nil || seats[request.Serial] != request.Fingerprint || activation.Fingerprint != request.Fingerprint {
+				http.NotFound(w, r)
+				return
+			}
+			delete(seats, request.Serial)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	options := func(host string, opts ...Option) []Option {
+		return append([]Option{
+			WithPublicKey(string(publicKey)),
+			WithActivationURL(server.URL + "/activation"),
+			WithActivationStore(dir),
+			WithHostFingerprint(host),
+			WithToken("secret"),
+			WithClock(func() time.Time { return now }),
+		}, opts...)
+	}
+	content, err := Issue(License{Serial: "foo-seat", Meta: map[string]interface{}{MetaMaxActivations: 1}, SignedOn: signedOn}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(host string) (*ValidationResult, error) {
+		license, err := New(content, options(host)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return license.Validate(nil)
+	}
+	// A seat-limited license must be activated on the machine
+	result, err := validate("aaaa")
+	if !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected a license not activated, got %v", err)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].Name != CheckActivation {
+		t.Errorf("Expected the activation check to fail, got %+v", failed)
+	}
+	activation, err := Activate(context.Background(), "foo-seat", options("aaaa")...)
+	if err != nil || activation.Fingerprint != "aaaa" {
+		t.Fatalf("Expected the license to be activated, got %+v (%v)", activation, err)
+	}
+	mutex.Lock()
+	if token != "Bearer secret" {
+		t.Errorf("Expected the token to be sent, got %q", token)
+	}
+	mutex.Unlock()
+	result, err = validate("aaaa")
+	if err != nil || result.Activation == nil || result.Activation.Serial != "foo-seat" {
+		t.Errorf("Expected an activated license, got %+v (%v)", result.Activation, err)
+	}
+	// The seat is taken: another machine is refused, the stored token doesn't match it
+	if _, err := Activate(context.Background(), "foo-seat", options("bbbb")...); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected the activation to be refused, got %v", err)
+	}
+	if _, err := validate("bbbb"); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected a license activated on another machine, got %v", err)
+	}
+	// Releasing the seat lets another machine activate the license
+	if err := Deactivate(context.Background(), "foo-seat", options("aaaa")...); err != nil {
+		t.Fatalf("Expected the license to be deactivated, got %v", err)
+	}
+	if err := Deactivate(context.Background(), "foo-seat", options("aaaa")...); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected a license not activated, got %v", err)
+	}
+	if _, err := Activate(context.Background(), "foo-seat", options("bbbb")...); err != nil {
+		t.Errorf("Expected the license to be activated, got %v", err)
+	}
+	// A token unknown to the API (Eg: the seat was released by the licensor) is stale and removed
+	mutex.Lock()
+	delete(seats, "foo-seat")
+	mutex.Unlock()
+	if err := Deactivate(context.Background(), "foo-seat", options("bbbb")...); err != nil {
+		t.Errorf("Expected the stale token to be removed, got %v", err)
+	}
+	if _, err := validate("bbbb"); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected the stale token to be removed, got %v", err)
+	}
+	if _, err := Activate(context.Background(), "foo-seat", options("bbbb")...); err != nil {
+		t.Errorf("Expected the license to be activated, got %v", err)
+	}
+	// The activation expires
+	now = now.AddDate(0, 2, 0)
+	if _, err := validate("bbbb"); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Expected an expired activation, got %v", err)
+	}
+	// Licenses which are not seat-limited are not checked
+	content, err = Issue(License{Serial: "foo-floating", Meta: map[string]interface{}{"seats": 10}, SignedOn: signedOn}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := validate("cccc"); err != nil || result.Activation != nil {
+		t.Errorf("Expected a license without activation, got %+v (%v)", result, err)
+	}
+	// Tokens signed by another key are rejected
+	otherKey, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := IssueActivation(Activation{Serial: "foo-seat", Fingerprint: "aaaa"}, otherKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseActivation(forged, keyring); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a forged activation to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of failure returned by this package; match them with errors.Is
+var (
+	// ErrInvalidFormat means the license (or one of its headers) is malformed
+	ErrInvalidFormat = errors.New("Invalid license format")
+	// ErrInvalidSignature means the license signature is malformed or doesn't match its message
+	ErrInvalidSignature = errors.New("Invalid license signature")
+	// ErrInvalidKey means the public key is malformed or not supported
+	ErrInvalidKey = errors.New("Invalid public key")
+	// ErrKeyRetired means the license is signed with a key which is no longer trusted (see TrustedKey.NotAfter and RevokedOn)
+	ErrKeyRetired = errors.New("Retired public key")
+	// ErrUntrustedKey means the public key doesn't match the pinned fingerprints or changed since it was cached
+	ErrUntrustedKey = errors.New("Untrusted public key")
+	// ErrMetadataMismatch means a desired metadata is missing or different into the license
+	ErrMetadataMismatch = errors.New("License metadata mismatch")
+	// ErrPolicyViolation means the license doesn't satisfy a policy (issuer, key ID, algorithm, remaining validity)
+	ErrPolicyViolation = errors.New("License policy violation")
+	// ErrRevoked means the license is listed by the revocation list (see RevokedError)
+	ErrRevoked = errors.New("License revoked")
+	// ErrRevocationListExpired means the revocation list is past its expiry date (see RevocationList.ExpiresOn)
+	ErrRevocationListExpired = errors.New("Revocation list expired")
+	// ErrInactive means the licensor API reports the license is not active (see InactiveError)
+	ErrInactive = errors.New("License not active")
+	// ErrFingerprintMismatch means the license is bound to other machines (see MetaFingerprint)
+	ErrFingerprintMismatch = errors.New("License bound to another machine")
+	// ErrNotActivated means the seat-limited license has no valid activation on this machine (see Activate)
+	ErrNotActivated = errors.New("License not activated")
+	// ErrExpired means the license is expired (see ExpiredError)
+	ErrExpired = errors.New("License expired")
+	// ErrNotYetValid means the license is used before it was signed (see NotYetValidError)
+	ErrNotYetValid = errors.New("License not yet valid")
+	// ErrFetch means the license or the public key could not be read (URL, file, ...)
+	ErrFetch = errors.New("Unable to fetch resource")
+	// ErrInvalidOption means the license was configured with a wrong option
+	ErrInvalidOption = errors.New("Invalid option")
+)
+
+// Error is a failure of a given kind (one of the Err* values) with its cause (if any)
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+// Building a new failure of the given kind
+func newError(kind error, err error, format string, args ...interface{}) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Is makes errors.Is(err, ErrXxx) work with the kind of the failure
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap offers the cause of the failure
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MetadataMismatchError is returned when a desired metadata is missing or different into the license
+type MetadataMismatchError struct {
+	Key      string
+	Expected interface{}
+	Actual   interface{}
+	Missing  bool
+	// Reason describes why the actual value doesn't satisfy the expected one (Eg: "expected >= 10, got 5")
+	Reason string
+}
+
+func (e *MetadataMismatchError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf(`Unable to verify the presence for metadata %q into the license`, e.Key)
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf(`Metadata %q mismatch: %s`, e.Key, e.Reason)
+	}
+	return fmt.Sprintf(`Metadata %q mismatch: expected %v, got %v`, e.Key, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is(err, ErrMetadataMismatch) work
+func (e *MetadataMismatchError) Is(target error) bool {
+	return target == ErrMetadataMismatch
+}
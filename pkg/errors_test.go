@@ -0,0 +1,74 @@
+package license
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestErrorKinds(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := os.ReadFile("../test/assets/public.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	newLicense := func(license string, opts ...Option) (*License, error) {
+		return New(license, append([]Option{WithPublicKey(string(publicKey))}, opts...)...)
+	}
+	validate := func(license string, now string, meta map[string]interface{}) error {
+		parsed, err := newLicense(license, WithClock(fixedClock(now)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parsed.Validate(meta)
+		return err
+	}
+	// Errors returned at construction
+	_, err = newLicense("not a license")
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat, got %v", err)
+	}
+	_, err = newLicense(string(content), WithPublicKey("not a key"))
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	_, err = newLicense(server.URL + "/license")
+	if !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected ErrFetch, got %v", err)
+	}
+	_, err = newLicense(string(content), WithGracePeriod(-time.Hour))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+	// Errors returned by validation
+	tampered := strings.Replace(string(content), "A144109", "A144110", 1)
+	err = validate(tampered, "2022-05-01T00:00:00Z", nil)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+	err = validate(string(content), "2022-05-01T00:00:00Z", map[string]interface{}{"agency": "A144110"})
+	var mismatchErr *MetadataMismatchError
+	if !errors.Is(err, ErrMetadataMismatch) || !errors.As(err, &mismatchErr) || mismatchErr.Key != "agency" {
+		t.Errorf("Expected a MetadataMismatchError on agency, got %v", err)
+	}
+	err = validate(string(content), "2022-07-01T00:00:00Z", nil)
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+	err = validate(string(content), "2022-01-01T00:00:00Z", nil)
+	if !errors.Is(err, ErrNotYetValid) {
+		t.Errorf("Expected ErrNotYetValid, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package fingerprint computes a stable ID of the current machine, used to bind node-locked licenses to a host
+package fingerprint
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnavailable means a component can't be read on this machine (Eg: no /etc/machine-id into a container)
+var ErrUnavailable = errors.New("Fingerprint component unavailable")
+
+// Component is a trait of the machine taken into its fingerprint
+type Component string
+
+const (
+	// MachineID is the ID of the operating system installation (/etc/machine-id, IOPlatformUUID, MachineGuid)
+	MachineID Component = "machine_id"
+	// MAC is the list of the hardware addresses of the physical network interfaces
+	MAC Component = "mac"
+	// CPU is the model of the processor
+	CPU Component = "cpu"
+	// Hostname is the name of the host
+	Hostname Component = "hostname"
+)
+
+// Components lists every supported component
+var Components = []Component{MachineID, MAC, CPU, Hostname}
+
+// DefaultComponents are the components used when none is configured; MAC is left out since network
+// interfaces come and go (Eg: USB adapters, VPN)
+var DefaultComponents = []Component{MachineID, CPU, Hostname}
+
+// Option configures how the fingerprint is computed
+type Option func(*options) error
+
+type options struct {
+	components []Component
+	hash       crypto.Hash
+	salt       []byte
+}
+
+// Building the options with their default values and applying the desired ones
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		components: DefaultComponents,
+		hash:       crypto.SHA256,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// WithComponents sets the components taken into the fingerprint (DefaultComponents otherwise)
+func WithComponents(components ...Component) Option {
+	return func(o *options) error {
+		if len(components) == 0 {
+			return errors.New("No fingerprint component")
+		}
+		for _, component := range components {
+			if _, err := ParseComponent(string(component)); err != nil {
+				return err
+			}
+		}
+		o.components = components
+		return nil
+	}
+}
+
+// WithHash sets the hash of the fingerprint: crypto.SHA256 (default) or crypto.SHA512
+func WithHash(hash crypto.Hash) Option {
+	return func(o *options) error {
+		if hash != crypto.SHA256 && hash != crypto.SHA512 {
+			return errors.Errorf("Unsupported fingerprint hash %s", hash)
+		}
+		o.hash = hash
+		return nil
+	}
+}
+
+// WithSalt computes the fingerprint as an HMAC keyed by the salt, so that the ID of a machine differs
+// from an application to another
+func WithSalt(salt []byte) Option {
+	return func(o *options) error {
+		o.salt = salt
+		return nil
+	}
+}
+
+// ParseComponent checks the name of a component
+func ParseComponent(value string) (Component, error) {
+	for _, component := range Components {
+		if string(component) == value {
+			return component, nil
+		}
+	}
+	return "", errors.Errorf("Unsupported fingerprint component %q", value)
+}
+
+// ParseHash reads the name of a fingerprint hash ("sha256" or "sha512")
+func ParseHash(value string) (crypto.Hash, error) {
+	switch strings.ToLower(value) {
+	case "sha256":
+		return crypto.SHA256, nil
+	case "sha512":
+		return crypto.SHA512, nil
+	}
+	return 0, errors.Errorf("Unsupported fingerprint hash %q (expected sha256 or sha512)", value)
+}
+
+// Compute is the fingerprint of the current machine: the hex encoded hash of its components
+func Compute(opts ...Option) (string, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	values, err := collect(o.components)
+	if err != nil {
+		return "", err
+	}
+	return digest(o, values), nil
+}
+
+// Collect reads the raw values of the components (Eg: to display them), without hashing them
+func Collect(components ...Component) (map[Component]string, error) {
+	if len(components) == 0 {
+		components = DefaultComponents
+	}
+	return collect(components)
+}
+
+// Equal compares fingerprints, whatever their case and surrounding spaces
+func Equal(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// Reading every component, failing on the first unavailable one
+func collect(components []Component) (map[Component]string, error) {
+	values := make(map[Component]string, len(components))
+	for _, component := range components {
+		value, err := read(component)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to read %s", component)
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			return nil, errors.Wrapf(ErrUnavailable, "Empty %s", component)
+		}
+		values[component] = value
+	}
+	return values, nil
+}
+
+// Hashing the components in a canonical form ("name=value" lines, sorted by name)
+func digest(o *options, values map[Component]string) string {
+	names := make([]string, 0, len(values))
+	for component := range values {
+		names = append(names, string(component))
+	}
+	sort.Strings(names)
+	newHash := sha256.New
+	if o.hash == crypto.SHA512 {
+		newHash = sha512.New
+	}
+	var h hash.Hash
+	if len(o.salt) > 0 {
+		h = hmac.New(newHash, o.salt)
+	} else {
+		h = newHash()
+	}
+	for _, name := range names {
+		h.Write([]byte(name + "=" + values[Component(name)] + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Reading a component with the OS specific readers
+func read(component Component) (string, error) {
+	switch component {
+	case MachineID:
+		return machineID()
+	case MAC:
+		return macAddresses()
+	case CPU:
+		return cpuModel()
+	case Hostname:
+		return os.Hostname()
+	}
+	return "", errors.Errorf("Unsupported fingerprint component %q", component)
+}
+
+// Listing the hardware addresses of the physical network interfaces (sorted, comma separated)
+// Loopback and locally administered (virtual, randomized) addresses are left out
+func macAddresses() (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to list network interfaces")
+	}
+	var addresses []string
+	for _, networkInterface := range interfaces {
+		address := networkInterface.HardwareAddr
+		if networkInterface.Flags&net.FlagLoopback != 0 || len(address) == 0 || address[0]&0x02 != 0 {
+			continue
+		}
+		addresses = append(addresses, address.String())
+	}
+	if len(addresses) == 0 {
+		return "", errors.Wrap(ErrUnavailable, "No physical network interface")
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ","), nil
+}
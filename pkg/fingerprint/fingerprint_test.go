@@ -0,0 +1,70 @@
+package fingerprint
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fingerprint is the hash of the canonical components
+	id, err := Compute(WithComponents(Hostname))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("hostname=" + strings.ToLower(strings.TrimSpace(hostname)) + "\n"))
+	if id != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected fingerprint %s", id)
+	}
+	// Components are hashed sorted by name, so that the fingerprint doesn't depend on their order
+	values := map[Component]string{Hostname: "foo-host", CPU: "foo cpu"}
+	o, err := newOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum = sha256.Sum256([]byte("cpu=foo cpu\nhostname=foo-host\n"))
+	plain := digest(o, values)
+	if plain != hex.EncodeToString(sum[:]) || !Equal(plain, " "+strings.ToUpper(plain)) {
+		t.Errorf("Unexpected fingerprint %s", plain)
+	}
+	// Hash and salt change the fingerprint
+	o, err = newOptions([]Option{WithSalt([]byte("my-app"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if salted := digest(o, values); salted == plain || len(salted) != 64 {
+		t.Errorf("Expected the salt to change the fingerprint, got %s", salted)
+	}
+	o, err = newOptions([]Option{WithHash(crypto.SHA512)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long := digest(o, values); len(long) != 128 {
+		t.Errorf("Expected a SHA-512 fingerprint, got %s", long)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"no component":      WithComponents(),
+		"unknown component": WithComponents("serial_number"),
+		"unsupported hash":  WithHash(crypto.MD5),
+	} {
+		if _, err := Compute(opt); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if hash, err := ParseHash("SHA512"); err != nil || hash != crypto.SHA512 {
+		t.Errorf("Expected SHA-512, got %v (%v)", hash, err)
+	}
+	if _, err := ParseHash("md5"); err == nil {
+		t.Errorf("Expected an unsupported hash")
+	}
+}
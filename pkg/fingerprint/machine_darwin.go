@@ -0,0 +1,32 @@
+package fingerprint
+
+import (
+	"os/exec"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var reUUID = regexp.MustCompile(`"IOPlatformUUID"\s*=\s*"([^"]+)"`)
+
+// Reading the hardware UUID of the Mac from the I/O Kit registry
+func machineID() (string, error) {
+	output, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", errors.Wrap(ErrUnavailable, err.Error())
+	}
+	matches := reUUID.FindSubmatch(output)
+	if matches == nil {
+		return "", errors.Wrap(ErrUnavailable, "No IOPlatformUUID")
+	}
+	return string(matches[1]), nil
+}
+
+// Reading the processor model from sysctl
+func cpuModel() (string, error) {
+	output, err := exec.Command("sysctl", "-n", "machdep.cpu.brand_string").Output()
+	if err != nil {
+		return "", errors.Wrap(ErrUnavailable, err.Error())
+	}
+	return string(output), nil
+}
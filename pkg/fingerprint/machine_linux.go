@@ -0,0 +1,48 @@
+package fingerprint
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reading the ID of the installation written by systemd (or D-Bus on older systems)
+func machineID() (string, error) {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		content, err := os.ReadFile(path)
+		if err == nil && strings.TrimSpace(string(content)) != "" {
+			return string(content), nil
+		}
+	}
+	return "", errors.Wrap(ErrUnavailable, "No /etc/machine-id nor /var/lib/dbus/machine-id")
+}
+
+// Reading the processor model from /proc/cpuinfo ("model name" on x86, "Hardware" or "CPU part" on ARM)
+func cpuModel() (string, error) {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return "", errors.Wrap(ErrUnavailable, err.Error())
+	}
+	defer file.Close()
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		name = strings.TrimSpace(name)
+		// The first processor is enough
+		if found && fields[name] == "" {
+			fields[name] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "Unable to read /proc/cpuinfo")
+	}
+	for _, name := range []string{"model name", "Hardware", "CPU part", "cpu model"} {
+		if fields[name] != "" {
+			return fields[name], nil
+		}
+	}
+	return "", errors.Wrap(ErrUnavailable, "No processor model into /proc/cpuinfo")
+}
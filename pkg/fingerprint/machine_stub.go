@@ -0,0 +1,18 @@
+//go:build !windows && !linux && !darwin
+// +build !windows,!linux,!darwin
+
+package fingerprint
+
+import (
+	"github.com/pkg/errors"
+)
+
+// No machine ID on this operating system
+func machineID() (string, error) {
+	return "", errors.Wrap(ErrUnavailable, "No machine ID on this operating system")
+}
+
+// No processor model on this operating system
+func cpuModel() (string, error) {
+	return "", errors.Wrap(ErrUnavailable, "No processor model on this operating system")
+}
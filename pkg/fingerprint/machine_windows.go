@@ -0,0 +1,33 @@
+package fingerprint
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reading the MachineGuid generated at the installation of Windows
+func machineID() (string, error) {
+	return registryValue(`HKLM\SOFTWARE\Microsoft\Cryptography`, "MachineGuid")
+}
+
+// Reading the processor model from the registry
+func cpuModel() (string, error) {
+	return registryValue(`HKLM\HARDWARE\DESCRIPTION\System\CentralProcessor\0`, "ProcessorNameString")
+}
+
+// Reading a registry value with reg.exe (output line: "    Name    REG_SZ    Value")
+func registryValue(key string, name string) (string, error) {
+	output, err := exec.Command("reg", "query", key, "/v", name).Output()
+	if err != nil {
+		return "", errors.Wrap(ErrUnavailable, err.Error())
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == name && strings.HasPrefix(fields[1], "REG_") {
+			return strings.Join(fields[2:], " "), nil
+		}
+	}
+	return "", errors.Wrapf(ErrUnavailable, "No %s into %s", name, key)
+}
@@ -0,0 +1,204 @@
+package license
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Header names as written by BuyMint inside the "====BEGIN LICENSE====" block
+const (
+	headerSerial         = "Serial"
+	headerLicensedTo     = "Licensed to"
+	headerMetadata       = "Metadata"
+	headerTransactionID  = "Transaction ID"
+	headerSubscriptionID = "Subscription ID"
+	headerExpiresOn      = "Expires on"
+	headerSignedOn       = "Signed on"
+	headerIssuedBy       = "Issued by"
+	headerIssuedFor      = "Issued for"
+	headerAlgorithm      = "Algorithm"
+	headerKeyID          = "Key ID"
+)
+
+// Identity is a party written in the license as `Name (Type: "Email")` (Eg: `Foo Test (user: "foo@test.cloud")`)
+type Identity struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Email string `json:"email"`
+}
+
+// String formats the identity the same way it is written into the license
+func (i Identity) String() string {
+	return fmt.Sprintf(`%s (%s: %q)`, i.Name, i.Type, i.Email)
+}
+
+var reIdentity = regexp.MustCompile(`^(.*?)\s*\(([^:()]*):\s*"([^"]*)"\)$`)
+
+// ParseIdentity parses an identity written as `Name (Type: "Email")` or as a bare name
+func ParseIdentity(value string) (Identity, error) {
+	if value == "" {
+		return Identity{}, nil
+	}
+	matches := reIdentity.FindStringSubmatch(value)
+	if matches == nil {
+		// A bare name (without type and email) is still acceptable
+		if strings.ContainsAny(value, `()"`) {
+			return Identity{}, errors.Errorf(`Invalid identity %q (expected: Name (type: "email"))`, value)
+		}
+		return Identity{Name: value}, nil
+	}
+	return Identity{
+		Name:  strings.TrimSpace(matches[1]),
+		Type:  strings.TrimSpace(matches[2]),
+		Email: matches[3],
+	}, nil
+}
+
+// Parsing a date header value (empty values are allowed and produce a zero time)
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, `Invalid date %q (expected RFC 3339)`, value)
+	}
+	return date, nil
+}
+
+// Parsing all the "Key: value" lines contained in the license message and storing them into the license
+func parseHeaders(body string, license *License) error {
+	seen := map[string]bool{}
+	for i, line := range strings.Split(body, "\n") {
+		// Line 1 is the "====BEGIN LICENSE====" marker itself
+		lineNumber := i + 1
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		separator := strings.Index(line, ":")
+		if separator < 0 {
+			return errors.Errorf(`Invalid license header at line %d: missing ":" separator in %q`, lineNumber, line)
+		}
+		key := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+		if seen[key] {
+			return errors.Errorf(`Invalid license header at line %d: duplicated %q`, lineNumber, key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case headerSerial:
+			license.Serial = value
+		case headerLicensedTo:
+			license.LicensedTo, err = ParseIdentity(value)
+		case headerMetadata:
+			license.Meta = map[string]interface{}{}
+			if value != "" {
+				err = json.Unmarshal([]byte(value), &license.Meta)
+			}
+		case headerTransactionID:
+			license.TransactionID = value
+		case headerSubscriptionID:
+			license.SubscriptionID = value
+		case headerExpiresOn:
+			license.ExpiresOn, err = parseDate(value)
+		case headerSignedOn:
+			license.SignedOn, err = parseDate(value)
+		case headerIssuedBy:
+			license.IssuedBy, err = ParseIdentity(value)
+		case headerIssuedFor:
+			license.IssuedFor = value
+		case headerAlgorithm:
+			license.Algorithm, err = parseAlgorithm(value)
+		case headerKeyID:
+			license.KeyID = value
+		default:
+			logger.Debug("Ignoring unknown license header %q at line %d", key, lineNumber)
+		}
+		if err != nil {
+			return errors.Wrapf(err, `Invalid license header %q at line %d`, key, lineNumber)
+		}
+	}
+	if license.Meta == nil {
+		license.Meta = map[string]interface{}{}
+	}
+	// Licenses issued before the "Algorithm" header are signed with RSA PKCS#1 v1.5
+	if license.Algorithm == "" {
+		license.Algorithm = RS256
+	}
+	return nil
+}
+
+// Formatting an identity header value (empty for a zero identity, bare name without type and email)
+func formatIdentity(identity Identity) (string, error) {
+	value := identity.String()
+	if identity == (Identity{}) {
+		value = ""
+	} else if identity.Type == "" && identity.Email == "" {
+		value = identity.Name
+	}
+	// Identities which can't be parsed back would make the license unreadable
+	if parsed, err := ParseIdentity(value); err != nil || parsed != identity {
+		return "", errors.Errorf("Invalid identity %+v: it can't be written into a license", identity)
+	}
+	return value, nil
+}
+
+// Formatting a date header value (empty for a zero time)
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(time.RFC3339)
+}
+
+// Writing the "Key: value" lines of the license message, in the order BuyMint writes them
+func formatHeaders(license *License) (string, error) {
+	licensedTo, err := formatIdentity(license.LicensedTo)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid %q", headerLicensedTo)
+	}
+	issuedBy, err := formatIdentity(license.IssuedBy)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid %q", headerIssuedBy)
+	}
+	meta := license.Meta
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid %q", headerMetadata)
+	}
+	headers := [][2]string{
+		{headerSerial, license.Serial},
+		{headerLicensedTo, licensedTo},
+		{headerMetadata, string(metadata)},
+		{headerTransactionID, license.TransactionID},
+		{headerSubscriptionID, license.SubscriptionID},
+		{headerExpiresOn, formatDate(license.ExpiresOn)},
+		{headerSignedOn, formatDate(license.SignedOn)},
+		{headerIssuedBy, issuedBy},
+		{headerIssuedFor, license.IssuedFor},
+		{headerAlgorithm, string(license.Algorithm)},
+	}
+	if license.KeyID != "" {
+		headers = append(headers, [2]string{headerKeyID, license.KeyID})
+	}
+	var body strings.Builder
+	for _, header := range headers {
+		// Values are written on a single line and trimmed when parsed
+		if strings.ContainsAny(header[1], "\r\n") || strings.TrimSpace(header[1]) != header[1] {
+			return "", errors.Errorf("Invalid %q: %q can't be written into a license", header[0], header[1])
+		}
+		body.WriteString(header[0] + ": " + header[1] + "\n")
+	}
+	return body.String(), nil
+}
@@ -0,0 +1,58 @@
+package license
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExtractLicenseHeaders(t *testing.T) {
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := extractLicenseData(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if license.Serial != "foo-test-alpha" {
+		t.Errorf("Serial: got %q, want %q", license.Serial, "foo-test-alpha")
+	}
+	if license.LicensedTo != (Identity{}) {
+		t.Errorf("Licensed to: got %+v, want an empty identity", license.LicensedTo)
+	}
+	if license.Meta["agency"] != "A144109" {
+		t.Errorf("Metadata: got %v", license.Meta)
+	}
+	if license.TransactionID != "" || license.SubscriptionID != "" || license.IssuedFor != "" {
+		t.Errorf("Expected empty transaction/subscription/issued for, got %q/%q/%q", license.TransactionID, license.SubscriptionID, license.IssuedFor)
+	}
+	expiresOn := time.Date(2022, 5, 29, 15, 2, 0, 0, time.UTC)
+	if !license.ExpiresOn.Equal(expiresOn) {
+		t.Errorf("Expires on: got %s, want %s", license.ExpiresOn, expiresOn)
+	}
+	signedOn := time.Date(2022, 4, 29, 15, 3, 37, 0, time.UTC)
+	if !license.SignedOn.Equal(signedOn) {
+		t.Errorf("Signed on: got %s, want %s", license.SignedOn, signedOn)
+	}
+	issuedBy := Identity{Name: "Foo Test", Type: "user", Email: "foo@test.cloud"}
+	if license.IssuedBy != issuedBy {
+		t.Errorf("Issued by: got %+v, want %+v", license.IssuedBy, issuedBy)
+	}
+}
+
+func TestExtractMalformedHeaders(t *testing.T) {
+	malformed := map[string]string{
+		"missing separator": "Serial foo",
+		"duplicated":        "Serial: foo\nSerial: bar",
+		"invalid date":      "Expires on: tomorrow",
+		"invalid identity":  `Issued by: Foo (user "foo@test.cloud")`,
+		"invalid metadata":  "Metadata: {agency}",
+	}
+	for name, headers := range malformed {
+		license := "====BEGIN LICENSE====\n" + headers + "\n=====END LICENSE=====\n====BEGIN SIGNATURE====\nAA==\n====END SIGNATURE===="
+		if _, err := extractLicenseData([]byte(license)); err == nil {
+			t.Errorf("%s: expected an error, got none", name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package license
+
+import (
+	"crypto"
+	"encoding/base64"
+	"time"
+)
+
+// Issue builds the license described by the template and signs it with the licensor private key
+// The template algorithm defaults to the one of the key (RS256 for RSA keys) and its "Signed on" date to now;
+// the returned license is the text read by New (Eg: written to a file)
+func Issue(template License, key crypto.Signer) (string, error) {
+	if template.Serial == "" {
+		return "", newError(ErrInvalidOption, nil, "Missing license serial")
+	}
+	if key == nil {
+		return "", newError(ErrInvalidKey, nil, "Missing private key")
+	}
+	if template.Algorithm == "" {
+		algorithm, err := defaultAlgorithm(key.Public())
+		if err != nil {
+			return "", err
+		}
+		template.Algorithm = algorithm
+	}
+	algorithm, err := parseAlgorithm(string(template.Algorithm))
+	if err != nil {
+		return "", newError(ErrInvalidOption, err, "Unable to issue license")
+	}
+	if template.SignedOn.IsZero() {
+		template.SignedOn = time.Now().UTC()
+	}
+	// Dates are written to the second
+	template.SignedOn = template.SignedOn.Truncate(time.Second)
+	template.ExpiresOn = template.ExpiresOn.Truncate(time.Second)
+	if !template.ExpiresOn.IsZero() && !template.ExpiresOn.After(template.SignedOn) {
+		return "", newError(ErrInvalidOption, nil, "License would expire (%s) before being signed (%s)", formatDate(template.ExpiresOn), formatDate(template.SignedOn))
+	}
+	headers, err := formatHeaders(&template)
+	if err != nil {
+		return "", newError(ErrInvalidOption, err, "Unable to issue license")
+	}
+	message := "====BEGIN LICENSE====\n" + headers + "=====END LICENSE====="
+	signature, err := sign(algorithm, key, []byte(message))
+	if err != nil {
+		return "", err
+	}
+	return message + "\n====BEGIN SIGNATURE====\n" + base64.StdEncoding.EncodeToString(signature) + "\n====END SIGNATURE====\n", nil
+}
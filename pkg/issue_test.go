@@ -0,0 +1,79 @@
+package license
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestIssue(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	template := License{
+		Serial:        "foo-issued",
+		LicensedTo:    Identity{Name: "Foo Customer", Type: "organization", Email: "license@foo.test"},
+		Meta:          map[string]interface{}{"agency": "A144109", "seats": float64(10)},
+		TransactionID: "tx-1",
+		ExpiresOn:     signedOn.AddDate(1, 0, 0),
+		SignedOn:      signedOn,
+		IssuedBy:      Identity{Name: "Foo Test", Type: "user", Email: "foo@test.cloud"},
+		IssuedFor:     "on-prem",
+	}
+	for _, algorithm := range Algorithms {
+		key, err := GenerateKey(algorithm, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, err := EncodePublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.Algorithm = algorithm
+		template.KeyID = string(algorithm) + "-key"
+		content, err := Issue(template, key)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		// Issued licenses round-trip through New and Validate
+		license, err := New(content, WithPublicKey(string(publicKey)), WithClock(fixedClock("2022-06-01T00:00:00Z")))
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if _, err := license.Validate(map[string]interface{}{"agency": "A144109"}); err != nil {
+			t.Errorf("%s: expected a valid license, got %v", algorithm, err)
+		}
+		if license.Serial != template.Serial || license.LicensedTo != template.LicensedTo || license.IssuedBy != template.IssuedBy ||
+			license.TransactionID != template.TransactionID || license.IssuedFor != template.IssuedFor || license.KeyID != template.KeyID ||
+			!license.ExpiresOn.Equal(template.ExpiresOn) || !license.SignedOn.Equal(template.SignedOn) || !reflect.DeepEqual(license.Meta, template.Meta) {
+			t.Errorf("%s: issued license differs from its template: %+v", algorithm, license)
+		}
+	}
+	// The algorithm defaults to the one of the key
+	key, err := GenerateKey(ES384, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := Issue(License{Serial: "foo-default"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if license, err := extractLicenseData([]byte(content)); err != nil || license.Algorithm != ES384 || license.SignedOn.IsZero() {
+		t.Errorf("Expected an ES384 license signed now, got %+v (%v)", license, err)
+	}
+	// Invalid templates
+	invalid := map[string]License{
+		"missing serial":     {},
+		"multi-line serial":  {Serial: "foo\nExpires on: 2099-01-01T00:00:00Z"},
+		"invalid identity":   {Serial: "foo", IssuedBy: Identity{Name: "Foo (bar)"}},
+		"expired":            {Serial: "foo", SignedOn: signedOn, ExpiresOn: signedOn.Add(-time.Hour)},
+		"unsuited algorithm": {Serial: "foo", Algorithm: RS256},
+	}
+	for name, template := range invalid {
+		if _, err := Issue(template, key); !errors.Is(err, ErrInvalidOption) && !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: expected an error, got %v", name, err)
+		}
+	}
+}
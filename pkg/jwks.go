@@ -0,0 +1,151 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// JWK is a JSON Web Key (RFC 7517) holding a public key
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ParseJWKS builds a keyring from a JSON Web Key Set: RSA, EC (P-256, P-384) and OKP (Ed25519) keys are supported
+// Keys not meant for signatures or of unknown type/algorithm are skipped
+func ParseJWKS(data []byte) (*Keyring, error) {
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, newError(ErrInvalidKey, err, "Unable to parse JWKS")
+	}
+	keyring := &Keyring{}
+	for i, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			logger.Debug("Skipping JWK %d (kid: %q) used for %q", i, jwk.KeyID, jwk.Use)
+			continue
+		}
+		var algorithm Algorithm
+		if jwk.Algorithm != "" {
+			parsedAlgorithm, err := parseAlgorithm(jwk.Algorithm)
+			if err != nil {
+				logger.Debug("Skipping JWK %d (kid: %q): %s", i, jwk.KeyID, err)
+				continue
+			}
+			algorithm = parsedAlgorithm
+		}
+		key, err := jwk.PublicKey()
+		if errors.Is(err, errUnsupportedJWK) {
+			logger.Debug("Skipping JWK %d (kid: %q): %s", i, jwk.KeyID, err)
+			continue
+		}
+		if err != nil {
+			return nil, newError(ErrInvalidKey, err, "Invalid JWK %d (kid: %q)", i, jwk.KeyID)
+		}
+		if err := keyring.Add(TrustedKey{ID: jwk.KeyID, Key: key, Algorithm: algorithm}); err != nil {
+			return nil, err
+		}
+	}
+	if len(keyring.keys) == 0 {
+		return nil, newError(ErrInvalidKey, nil, "No usable key into JWKS")
+	}
+	return keyring, nil
+}
+
+// LoadJWKS reads a JSON Web Key Set from a source (Eg: FromFile, FromURL) and builds its keyring
+func LoadJWKS(ctx context.Context, source KeySource) (*Keyring, error) {
+	data, err := source.ReadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// Cause of JWKs skipped because of their unknown type or curve
+var errUnsupportedJWK = errors.New("Unsupported JWK")
+
+// PublicKey decodes the public key held by the JWK
+func (jwk JWK) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, errors.Wrap(err, `Invalid "n"`)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, errors.Wrap(err, `Invalid "e"`)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New(`Invalid "e"`)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, errors.Wrapf(errUnsupportedJWK, "curve %q", jwk.Curve)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, errors.Wrap(err, `Invalid "x"`)
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, `Invalid "y"`)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("Point is not on curve " + jwk.Curve)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, errors.Wrapf(errUnsupportedJWK, "curve %q", jwk.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New(`Invalid "x"`)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.Wrapf(errUnsupportedJWK, "key type %q", jwk.KeyType)
+}
+
+// Decoding a base64url (unpadded) big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) == 0 {
+		return nil, errors.New("Empty value")
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,105 @@
+package license
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Encoding a public key as JWK
+func testJWK(t *testing.T, key crypto.PublicKey, kid string, algorithm Algorithm) JWK {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{KeyType: "RSA", KeyID: kid, Algorithm: string(algorithm), N: encode(k.N.Bytes()), E: encode(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return JWK{KeyType: "EC", KeyID: kid, Algorithm: string(algorithm), Curve: k.Curve.Params().Name, X: encode(k.X.FillBytes(make([]byte, size))), Y: encode(k.Y.FillBytes(make([]byte, size)))}
+	case ed25519.PublicKey:
+		return JWK{KeyType: "OKP", KeyID: kid, Algorithm: string(algorithm), Curve: "Ed25519", X: encode(k)}
+	}
+	t.Fatalf("Unsupported key %T", key)
+	return JWK{}
+}
+
+func TestJWKS(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := JWKS{Keys: []JWK{
+		testJWK(t, rsaKey.Public(), "rsa", PS256),
+		testJWK(t, ecKey.Public(), "ec", ES256),
+		testJWK(t, edKey.Public(), "ed", ""),
+		// Skipped keys
+		{KeyType: "oct", KeyID: "secret"},
+		{KeyType: "EC", KeyID: "p521", Curve: "P-521"},
+		{KeyType: "OKP", KeyID: "encryption", Curve: "X25519", Use: "enc"},
+	}}
+	document, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	}))
+	defer server.Close()
+	licenses := map[string]string{
+		"rsa": testSignLicense(t, PS256, rsaKey, "Serial: rsa\nAlgorithm: PS256\nKey ID: rsa"),
+		"ec":  testSignLicense(t, ES256, ecKey, "Serial: ec\nAlgorithm: ES256\nKey ID: ec"),
+		"ed":  testSignLicense(t, EdDSA, edKey, "Serial: ed\nAlgorithm: EdDSA\nKey ID: ed"),
+	}
+	for name, content := range licenses {
+		license, err := New(content, WithJWKS(FromURL(server.URL+"/.well-known/jwks.json")))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := license.Validate(nil); err != nil {
+			t.Errorf("%s: expected a valid license, got %v", name, err)
+		}
+	}
+	// The "alg" of the JWK restricts the key
+	content := testSignLicense(t, RS256, rsaKey, "Serial: rsa\nKey ID: rsa")
+	license, err := New(content, WithJWKS(FromString(string(document))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature for an algorithm not allowed by the JWK, got %v", err)
+	}
+	// Malformed key sets
+	malformed := []string{
+		`not json`,
+		`{"keys": []}`,
+		`{"keys": [{"kty": "RSA", "kid": "bad", "n": "!", "e": "AQAB"}]}`,
+		`{"keys": [{"kty": "EC", "kid": "bad", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+		`{"keys": [{"kty": "OKP", "kid": "bad", "crv": "Ed25519", "x": "AQ"}]}`,
+	}
+	for _, document := range malformed {
+		if _, err := ParseJWKS([]byte(document)); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: expected ErrInvalidKey, got %v", document, err)
+		}
+	}
+}
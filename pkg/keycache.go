@@ -0,0 +1,210 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Fingerprint is the SHA-256 (hex encoded) of the PKIX (DER) encoding of a public key
+func Fingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", newError(ErrInvalidKey, err, "Unable to encode public key")
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Normalizing a fingerprint written as hex, with or without colons (Eg: "AB:CD:..." or "abcd...")
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// Checking the key matches one of the pinned fingerprints (every key matches when nothing is pinned)
+func checkPins(key crypto.PublicKey, pins []string) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	fingerprint, err := Fingerprint(key)
+	if err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		if pin == fingerprint {
+			return nil
+		}
+	}
+	return newError(ErrUntrustedKey, nil, "Public key %s doesn't match the pinned fingerprints", fingerprint)
+}
+
+// DefaultKeyCacheDir is the directory where public keys are cached (under the user cache directory)
+func DefaultKeyCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buymint-cli", "keys"), nil
+}
+
+// keyCache stores fetched public keys on disk, one file per URL
+type keyCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// keyCacheEntry is the content of a cache file
+type keyCacheEntry struct {
+	URL         string    `json:"url"`
+	ETag        string    `json:"etag,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Fingerprint string    `json:"fingerprint"`
+	Key         string    `json:"key"`
+}
+
+// Path of the cache file of an URL
+func (c *keyCache) path(URL string) string {
+	sum := sha256.Sum256([]byte(URL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Reading the cache entry of an URL (nil if missing or unreadable)
+func (c *keyCache) read(URL string) *keyCacheEntry {
+	content, err := os.ReadFile(c.path(URL))
+	if err != nil {
+		return nil
+	}
+	var entry keyCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.URL != URL {
+		logger.Warn("Ignoring corrupted key cache entry for %q", URL)
+		return nil
+	}
+	return &entry
+}
+
+// Writing the cache entry of an URL (atomically, readable by current user only)
+func (c *keyCache) write(entry *keyCacheEntry) error {
+	return writeCacheFile(c.dir, c.path(entry.URL), entry)
+}
+
+// Writing a cache entry as JSON (atomically, readable by current user only)
+func writeCacheFile(dir string, path string, entry interface{}) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".cache-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Converting the key of a cache entry, ensuring it wasn't altered
+func (entry *keyCacheEntry) publicKey() (crypto.PublicKey, error) {
+	publicKey, err := convertPublicKey([]byte(entry.Key))
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := Fingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != entry.Fingerprint {
+		return nil, newError(ErrUntrustedKey, nil, "Cached public key of %q was altered", entry.URL)
+	}
+	return publicKey, nil
+}
+
+// Loading the public key of an URL: a fresh cached key is used as is, a stale one is revalidated (If-None-Match)
+// and used offline when the URL is unreachable; a key different from the cached one is never trusted unless pinned
+func (c *keyCache) load(ctx context.Context, source *URLSource, o *options) (crypto.PublicKey, error) {
+	now := o.clock()
+	entry := c.read(source.URL)
+	if entry != nil && now.Sub(entry.FetchedAt) < c.ttl {
+		logger.Debug("Using cached public key of %q (fetched at %s)", source.URL, entry.FetchedAt)
+		publicKey, err := entry.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		return publicKey, checkPins(publicKey, o.keyPins)
+	}
+	etag := ""
+	if entry != nil {
+		etag = entry.ETag
+	}
+	status, content, newETag, err := source.fetchConditional(ctx, etag)
+	if err != nil {
+		if entry == nil {
+			return nil, errors.Wrap(err, `Unable to parse public key`)
+		}
+		logger.Warn("Using stale cached public key of %q: %s", source.URL, err)
+		publicKey, err := entry.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		return publicKey, checkPins(publicKey, o.keyPins)
+	}
+	if status == http.StatusNotModified && entry != nil {
+		publicKey, err := entry.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPins(publicKey, o.keyPins); err != nil {
+			return nil, err
+		}
+		entry.FetchedAt = now
+		if err := c.write(entry); err != nil {
+			logger.Warn("Unable to update key cache: %s", err)
+		}
+		return publicKey, nil
+	}
+	// Converting the fetched key
+	publicKey, err := convertPublicKey(content)
+	if err != nil {
+		return nil, errors.Wrap(err, `Unable to convert public key`)
+	}
+	fingerprint, err := Fingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPins(publicKey, o.keyPins); err != nil {
+		return nil, err
+	}
+	// A changed key is trusted only when explicitly pinned
+	if entry != nil && entry.Fingerprint != fingerprint && len(o.keyPins) == 0 {
+		return nil, newError(ErrUntrustedKey, nil, "Public key of %q changed from %s to %s; pin the new fingerprint to trust it", source.URL, entry.Fingerprint, fingerprint)
+	}
+	if err := c.write(&keyCacheEntry{
+		URL:         source.URL,
+		ETag:        newETag,
+		FetchedAt:   now,
+		Fingerprint: fingerprint,
+		Key:         string(content),
+	}); err != nil {
+		logger.Warn("Unable to write key cache: %s", err)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,137 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestKeyCache(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetKey, err := os.ReadFile("../test/assets/public.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetPublicKey, err := convertPublicKey(assetKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetFingerprint, err := Fingerprint(assetPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedKey := testPublicKeyPEM(t, rotatedPublicKey)
+	rotatedFingerprint, err := Fingerprint(rotatedPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Key server supporting ETag revalidation
+	var mutex sync.Mutex
+	served, requests, conditionalRequests := string(assetKey), 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		sum := sha256.Sum256([]byte(served))
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		if r.Header.Get("If-None-Match") != "" {
+			conditionalRequests++
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(served))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	now := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	load := func(opts ...Option) error {
+		opts = append([]Option{
+			WithPublicKey(server.URL + "/key"),
+			WithKeyCache(dir, time.Hour),
+			WithClock(func() time.Time { return now }),
+		}, opts...)
+		_, err := New(string(content), opts...)
+		return err
+	}
+	// The first load fetches and caches the key
+	if err := load(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one cache file, got %v (%v)", files, err)
+	}
+	if info, err := os.Stat(files[0]); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a cache file readable by current user only, got %v (%v)", info.Mode(), err)
+	}
+	// A fresh cached key is used without any request
+	if err := load(); err != nil || requests != 1 {
+		t.Errorf("Expected the cached key to be used, got %d requests (%v)", requests, err)
+	}
+	// A stale cached key is revalidated
+	now = now.Add(2 * time.Hour)
+	if err := load(); err != nil || requests != 2 || conditionalRequests != 1 {
+		t.Errorf("Expected a conditional request, got %d requests, %d conditional (%v)", requests, conditionalRequests, err)
+	}
+	// A changed key is never silently trusted
+	mutex.Lock()
+	served = rotatedKey
+	mutex.Unlock()
+	now = now.Add(2 * time.Hour)
+	if err := load(); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Expected ErrUntrustedKey for a changed key, got %v", err)
+	}
+	if err := load(WithKeyPin(assetFingerprint)); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Expected ErrUntrustedKey for a changed key not matching the pin, got %v", err)
+	}
+	if err := load(WithKeyPin(strings.ToUpper(rotatedFingerprint))); err != nil {
+		t.Errorf("Expected the pinned changed key to be trusted, got %v", err)
+	}
+	// A stale cached key is used when the key server is unreachable
+	server.Close()
+	now = now.Add(2 * time.Hour)
+	if err := load(WithKeyPin(rotatedFingerprint)); err != nil {
+		t.Errorf("Expected the stale cached key to be used offline, got %v", err)
+	}
+	// An altered cached key is never trusted
+	entry, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	altered := strings.Replace(string(entry), rotatedFingerprint, assetFingerprint, 1)
+	if err := os.WriteFile(files[0], []byte(altered), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := load(); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Expected ErrUntrustedKey for an altered cache, got %v", err)
+	}
+	// Invalid options
+	for _, opt := range []Option{WithKeyPin("abcd"), WithKeyPin(strings.Repeat("z", 64)), WithKeyCache(dir, 0)} {
+		if err := load(opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Expected ErrInvalidOption, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package license
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+)
+
+// DefaultRSABits is the size of the RSA keys generated when none is specified
+const DefaultRSABits = 3072
+
+// Smallest RSA key size accepted by GenerateKey
+const minRSABits = 2048
+
+// GenerateKey generates a licensor private key suited to the signature algorithm:
+// RSA (RS256, PS256, PS512) of the given bits (DefaultRSABits if 0), ECDSA P-256 (ES256), P-384 (ES384) or Ed25519 (EdDSA)
+func GenerateKey(algorithm Algorithm, bits int) (crypto.Signer, error) {
+	algorithm, err := parseAlgorithm(string(algorithm))
+	if err != nil {
+		return nil, newError(ErrInvalidOption, err, "Unable to generate key")
+	}
+	var key crypto.Signer
+	switch algorithm {
+	case RS256, PS256, PS512:
+		if bits == 0 {
+			bits = DefaultRSABits
+		}
+		if bits < minRSABits {
+			return nil, newError(ErrInvalidOption, nil, "RSA keys must have at least %d bits, got %d", minRSABits, bits)
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ES384:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, newError(ErrInvalidKey, err, "Unable to generate %s key", algorithm)
+	}
+	return key, nil
+}
+
+// ParsePrivateKey decodes a PEM private key (PKCS #1, SEC 1 or PKCS #8), decrypting it with the passphrase if needed
+// (encrypted PKCS #8 with PBES2, PBKDF2 and AES-CBC, Eg: "openssl pkcs8 -topk8 -v2 aes-256-cbc")
+func ParsePrivateKey(key []byte, passphrase []byte) (crypto.Signer, error) {
+	return convertPrivateKey(key, passphrase)
+}
+
+// EncodePrivateKey encodes a private key as PKCS #8 PEM, encrypted with the passphrase if not empty
+// ("ENCRYPTED PRIVATE KEY": PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC, readable by OpenSSL)
+func EncodePrivateKey(key crypto.Signer, passphrase []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, newError(ErrInvalidKey, err, "Unable to encode private key")
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if len(passphrase) > 0 {
+		block, err = encryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, newError(ErrInvalidKey, err, "Unable to encrypt private key")
+		}
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// EncodePublicKey encodes a public key as PKIX PEM, the format expected by WithPublicKey
+func EncodePublicKey(key crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, newError(ErrInvalidKey, err, "Unable to encode public key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// KeyID derives a key ID (the "Key ID" license header) from the fingerprint of a public key
+func KeyID(key crypto.PublicKey) (string, error) {
+	fingerprint, err := Fingerprint(key)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint[:16], nil
+}
@@ -0,0 +1,117 @@
+package license
+
+import (
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestGenerateKey(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		key, err := GenerateKey(algorithm, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if err := checkKeyAlgorithm(algorithm, key.Public()); err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		}
+		// Private keys are read back, with or without passphrase
+		for _, passphrase := range []string{"", "secret"} {
+			encoded, err := EncodePrivateKey(key, []byte(passphrase))
+			if err != nil {
+				t.Fatalf("%s: %v", algorithm, err)
+			}
+			if block, _ := pem.Decode(encoded); passphrase != "" && (block == nil || block.Type != "ENCRYPTED PRIVATE KEY") {
+				t.Errorf("%s: expected an encrypted PKCS #8 key", algorithm)
+			}
+			parsed, err := ParsePrivateKey(encoded, []byte(passphrase))
+			if err != nil {
+				t.Fatalf("%s: %v", algorithm, err)
+			}
+			if !testSameKey(t, parsed.Public(), key.Public()) {
+				t.Errorf("%s: parsed key differs from the generated one", algorithm)
+			}
+			if passphrase != "" {
+				if _, err := ParsePrivateKey(encoded, []byte("wrong")); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("%s: expected ErrInvalidKey for a wrong passphrase, got %v", algorithm, err)
+				}
+				if _, err := ParsePrivateKey(encoded, nil); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("%s: expected ErrInvalidKey without passphrase, got %v", algorithm, err)
+				}
+			}
+		}
+		// Public keys are usable to verify licenses
+		encoded, err := EncodePublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := testSignLicense(t, algorithm, key, "Serial: keygen\nAlgorithm: "+string(algorithm))
+		license, err := New(content, WithPublicKey(string(encoded)))
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if _, err := license.Validate(nil); err != nil {
+			t.Errorf("%s: expected a valid license, got %v", algorithm, err)
+		}
+		if id, err := KeyID(key.Public()); err != nil || len(id) != 16 {
+			t.Errorf("%s: unexpected key ID %q (%v)", algorithm, id, err)
+		}
+	}
+	if _, err := GenerateKey(RS256, 1024); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a weak RSA key, got %v", err)
+	}
+	if _, err := GenerateKey("HS256", 0); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for an unsupported algorithm, got %v", err)
+	}
+}
+
+func TestParseEncryptedPrivateKey(t *testing.T) {
+	// Key encrypted by "openssl genpkey -algorithm ed25519 -aes-256-cbc -pass pass:secret"
+	encrypted, err := os.ReadFile("../test/assets/encrypted.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePrivateKey(encrypted, []byte("secret")); err != nil {
+		t.Errorf("Expected the OpenSSL key to be decrypted, got %v", err)
+	}
+	if _, err := ParsePrivateKey(encrypted, []byte("wrong")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a wrong passphrase, got %v", err)
+	}
+	// Legacy PEM encryption (RFC 1423, "Proc-Type: 4,ENCRYPTED") is refused
+	legacy, err := os.ReadFile("../test/assets/legacy-encrypted.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePrivateKey(legacy, []byte("secret")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a legacy encrypted key, got %v", err)
+	}
+	// PBKDF2 test vectors of RFC 6070, with the default pseudorandom function (HMAC-SHA1)
+	newHash, err := pbkdf2Hash(pkix.AlgorithmIdentifier{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iterations, expected := range map[int]string{1: "0c60c80f961f0e71f3a9b524af6012062fe037a6", 4096: "4b007901b765489abead49d926f721d065a429c1"} {
+		if key := hex.EncodeToString(pbkdf2.Key([]byte("password"), []byte("salt"), iterations, 20, newHash)); key != expected {
+			t.Errorf("PBKDF2 with %d iterations: got %s, want %s", iterations, key, expected)
+		}
+	}
+}
+
+// Comparing public keys by their fingerprint
+func testSameKey(t *testing.T, a, b crypto.PublicKey) bool {
+	fingerprintA, err := Fingerprint(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprintB, err := Fingerprint(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fingerprintA == fingerprintB
+}
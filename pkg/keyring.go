@@ -0,0 +1,162 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// TrustedKey is a public key trusted to sign licenses during its validity period
+type TrustedKey struct {
+	// ID is matched against the "Key ID" header of the license
+	ID  string
+	Key crypto.PublicKey
+	// Algorithm restricts the key to a single signature algorithm (any suited algorithm when empty)
+	Algorithm Algorithm
+	// NotBefore is the time the key starts signing: licenses signed before are rejected (no lower bound when zero)
+	NotBefore time.Time
+	// NotAfter is the time the key is retired: licenses signed after it are rejected, the ones signed before
+	// keep validating (never retired when zero). It only limits the "Signed on" date claimed by the license, which
+	// the signer chooses: whoever holds the key can still backdate licenses, use RevokedOn for a leaked key
+	NotAfter time.Time
+	// RevokedOn is the time the key stops being trusted at all (Eg: leaked): from then on, every license it signed
+	// is rejected whatever its "Signed on" date (never revoked when zero)
+	RevokedOn time.Time
+}
+
+// Keyring holds the public keys trusted to sign licenses, selected by the "Key ID" header of the license
+type Keyring struct {
+	keys []TrustedKey
+}
+
+// NewKeyring builds a keyring made of the given keys
+func NewKeyring(keys ...TrustedKey) (*Keyring, error) {
+	keyring := &Keyring{}
+	for _, key := range keys {
+		if err := keyring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return keyring, nil
+}
+
+// Add trusts a new key (its ID must be unique into the keyring)
+func (k *Keyring) Add(key TrustedKey) error {
+	if key.Key == nil {
+		return newError(ErrInvalidKey, nil, "Nil public key for key ID %q", key.ID)
+	}
+	if err := checkKeySupported(key.Key); err != nil {
+		return err
+	}
+	if _, err := parseAlgorithm(string(key.Algorithm)); key.Algorithm != "" && err != nil {
+		return newError(ErrInvalidKey, err, "Invalid algorithm for key ID %q", key.ID)
+	}
+	if !key.NotBefore.IsZero() && !key.NotAfter.IsZero() && key.NotAfter.Before(key.NotBefore) {
+		return newError(ErrInvalidKey, nil, "Key ID %q is retired before being trusted", key.ID)
+	}
+	for _, existing := range k.keys {
+		if existing.ID == key.ID {
+			return newError(ErrInvalidKey, nil, "Duplicated key ID %q", key.ID)
+		}
+	}
+	k.keys = append(k.keys, key)
+	return nil
+}
+
+// Keys lists the trusted keys
+func (k *Keyring) Keys() []TrustedKey {
+	return append([]TrustedKey(nil), k.keys...)
+}
+
+// Lookup selects the key with the given ID, checking it was trusted at the given signing time and is not revoked now
+// Licenses without "Key ID" (empty id) use the key without ID or the only key of the keyring
+func (k *Keyring) Lookup(id string, signedOn time.Time, now time.Time) (TrustedKey, error) {
+	key, found := k.find(id)
+	if !found {
+		if id == "" {
+			return TrustedKey{}, newError(ErrInvalidKey, nil, "License has no key ID and the keyring holds %d keys", len(k.keys))
+		}
+		return TrustedKey{}, newError(ErrInvalidKey, nil, "Unknown key ID %q", id)
+	}
+	if !key.RevokedOn.IsZero() && !now.Before(key.RevokedOn) {
+		return TrustedKey{}, newError(ErrKeyRetired, nil, "Key ID %q was revoked on %s, none of its signatures is trusted anymore", key.ID, key.RevokedOn.Format(time.RFC3339))
+	}
+	if !key.NotBefore.IsZero() && signedOn.Before(key.NotBefore) {
+		return TrustedKey{}, newError(ErrInvalidKey, nil, "Key ID %q is not trusted before %s, got a signature of %s", key.ID, key.NotBefore.Format(time.RFC3339), signedOn.Format(time.RFC3339))
+	}
+	if !key.NotAfter.IsZero() && signedOn.After(key.NotAfter) {
+		return TrustedKey{}, newError(ErrKeyRetired, nil, "Key ID %q was retired on %s, got a signature of %s", key.ID, key.NotAfter.Format(time.RFC3339), signedOn.Format(time.RFC3339))
+	}
+	return key, nil
+}
+
+func (k *Keyring) find(id string) (TrustedKey, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	// A single key matches licenses without key ID, a single key without ID matches every license
+	if len(k.keys) == 1 && (id == "" || k.keys[0].ID == "") {
+		return k.keys[0], true
+	}
+	return TrustedKey{}, false
+}
+
+// LoadKeyring resolves the keys trusted by the options (WithKeyring, WithJWKS, WithPublicKey, ...)
+// Use it with WithKeyring to share the fetched keys between many licenses
+func LoadKeyring(ctx context.Context, opts ...Option) (*Keyring, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return loadKeyring(ctx, o)
+}
+
+// Using the trusted keyring (given or read from JWKS) or, otherwise, the single public key
+func loadKeyring(ctx context.Context, o *options) (*Keyring, error) {
+	if o.keyring != nil {
+		return o.keyring, nil
+	}
+	if o.jwksSource != nil {
+		byteJWKS, err := readKey(ctx, o.jwksSource, o)
+		if err != nil {
+			return nil, errors.Wrap(err, `Unable to read JWKS`)
+		}
+		keyring, err := ParseJWKS(byteJWKS)
+		if err != nil {
+			return nil, errors.Wrap(err, `Unable to convert JWKS`)
+		}
+		return keyring, nil
+	}
+	publicKey, err := loadPublicKey(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{keys: []TrustedKey{{Key: publicKey}}}, nil
+}
+
+// Reading and converting the single public key (through the key cache when enabled), checking its pins
+func loadPublicKey(ctx context.Context, o *options) (crypto.PublicKey, error) {
+	urlSource, isURL := o.keySource.(*URLSource)
+	if o.keyCache != nil && isURL {
+		return o.keyCache.load(ctx, urlSource.withOptions(o), o)
+	}
+	bytePublicKey, err := readKey(ctx, o.keySource, o)
+	if err != nil {
+		return nil, errors.Wrap(err, `Unable to parse public key`)
+	}
+	logger.Debug("Converting public key:\n\n%s", bytePublicKey)
+	// Converting PublicKey
+	publicKey, err := convertPublicKey(bytePublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, `Unable to convert public key`)
+	}
+	if err := checkPins(publicKey, o.keyPins); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
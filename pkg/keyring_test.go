@@ -0,0 +1,121 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	oldPublic, oldPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPublic, newPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotation := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	keyring, err := NewKeyring(
+		TrustedKey{ID: "2021", Key: oldPublic, NotAfter: rotation.AddDate(0, 1, 0)},
+		TrustedKey{ID: "2022", Key: newPublic, Algorithm: EdDSA, NotBefore: rotation},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := func(date time.Time) string {
+		return "\nSigned on: " + date.Format(time.RFC3339)
+	}
+	oldLicense := testSignLicense(t, EdDSA, oldPrivate, "Serial: old\nAlgorithm: EdDSA\nKey ID: 2021"+signedOn(rotation.AddDate(0, -1, 0)))
+	newLicense := testSignLicense(t, EdDSA, newPrivate, "Serial: new\nAlgorithm: EdDSA\nKey ID: 2022"+signedOn(rotation.AddDate(0, 0, 1)))
+	validate := func(content string, now time.Time) error {
+		license, err := New(content, WithKeyring(keyring), WithClock(func() time.Time { return now }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = license.Validate(nil)
+		return err
+	}
+	// Both keys are trusted during the transition
+	transition := rotation.AddDate(0, 0, 7)
+	if err := validate(oldLicense, transition); err != nil {
+		t.Errorf("Expected old license to validate during transition, got %v", err)
+	}
+	if err := validate(newLicense, transition); err != nil {
+		t.Errorf("Expected new license to validate during transition, got %v", err)
+	}
+	// Licenses signed before the cutoff of the old key keep validating after it, the ones signed after are rejected
+	afterCutoff := rotation.AddDate(0, 2, 0)
+	if err := validate(oldLicense, afterCutoff); err != nil {
+		t.Errorf("Expected old license to validate after the cutoff, got %v", err)
+	}
+	lateLicense := testSignLicense(t, EdDSA, oldPrivate, "Serial: late\nAlgorithm: EdDSA\nKey ID: 2021"+signedOn(rotation.AddDate(0, 1, 1)))
+	if err := validate(lateLicense, afterCutoff); !errors.Is(err, ErrKeyRetired) {
+		t.Errorf("Expected ErrKeyRetired, got %v", err)
+	}
+	// NotAfter only limits the claimed signing date: a license signed after the cutoff but backdated still validates,
+	// until the key is revoked
+	backdatedLicense := testSignLicense(t, EdDSA, oldPrivate, "Serial: backdated\nAlgorithm: EdDSA\nKey ID: 2021"+signedOn(rotation.AddDate(0, 0, -1)))
+	if err := validate(backdatedLicense, afterCutoff); err != nil {
+		t.Errorf("Expected the backdated license to validate without revocation, got %v", err)
+	}
+	revoked, err := NewKeyring(TrustedKey{ID: "2021", Key: oldPublic, NotAfter: rotation.AddDate(0, 1, 0), RevokedOn: rotation.AddDate(0, 1, 15)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if license, err := New(oldLicense, WithKeyring(revoked), WithClock(func() time.Time { return transition })); err != nil {
+		t.Fatal(err)
+	} else if _, err := license.Validate(nil); err != nil {
+		t.Errorf("Expected old license to validate before the revocation, got %v", err)
+	}
+	for _, content := range []string{oldLicense, backdatedLicense} {
+		license, err := New(content, WithKeyring(revoked), WithClock(func() time.Time { return afterCutoff }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := license.Validate(nil); !errors.Is(err, ErrKeyRetired) {
+			t.Errorf("Expected ErrKeyRetired once the key is revoked, got %v", err)
+		}
+	}
+	// Without "Signed on", the key must be trusted at the validation time
+	undatedLicense := testSignLicense(t, EdDSA, oldPrivate, "Serial: undated\nAlgorithm: EdDSA\nKey ID: 2021")
+	if err := validate(undatedLicense, transition); err != nil {
+		t.Errorf("Expected undated license to validate during transition, got %v", err)
+	}
+	if err := validate(undatedLicense, afterCutoff); !errors.Is(err, ErrKeyRetired) {
+		t.Errorf("Expected ErrKeyRetired, got %v", err)
+	}
+	// The new key is not trusted to sign before the rotation
+	earlyLicense := testSignLicense(t, EdDSA, newPrivate, "Serial: early\nAlgorithm: EdDSA\nKey ID: 2022"+signedOn(rotation.AddDate(0, 0, -7)))
+	if err := validate(earlyLicense, transition); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey before rotation, got %v", err)
+	}
+	// A license can't pick a key by a wrong ID
+	forged := testSignLicense(t, EdDSA, oldPrivate, "Serial: forged\nAlgorithm: EdDSA\nKey ID: 2022")
+	if err := validate(forged, transition); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+	unknown := testSignLicense(t, EdDSA, oldPrivate, "Serial: unknown\nAlgorithm: EdDSA\nKey ID: 2020")
+	if err := validate(unknown, transition); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for an unknown key ID, got %v", err)
+	}
+	legacy := testSignLicense(t, EdDSA, oldPrivate, "Serial: legacy\nAlgorithm: EdDSA")
+	if err := validate(legacy, transition); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a license without key ID, got %v", err)
+	}
+	// Invalid keyrings
+	if err := keyring.Add(TrustedKey{ID: "2022", Key: newPublic}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a duplicated key ID, got %v", err)
+	}
+	if _, err := NewKeyring(TrustedKey{ID: "nil"}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a nil key, got %v", err)
+	}
+	if _, err := New(newLicense, WithKeyring(&Keyring{})); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for an empty keyring, got %v", err)
+	}
+}
@@ -1,180 +1,335 @@
 package license
 
 import (
+	"context"
 	"crypto"
-	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/json"
 	"encoding/pem"
-	"net/url"
-	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
-	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/rest"
 	"github.com/pkg/errors"
 )
 
 type License struct {
-	Signature string                 `json:"serial"`
-	Message   string                 `json:"message"`
-	Meta      map[string]interface{} `json:"meta"`
-	publicKey *rsa.PublicKey         `json:"-"`
+	Serial          string                 `json:"serial"`
+	LicensedTo      Identity               `json:"licensed_to"`
+	Meta            map[string]interface{} `json:"meta"`
+	TransactionID   string                 `json:"transaction_id"`
+	SubscriptionID  string                 `json:"subscription_id"`
+	ExpiresOn       time.Time              `json:"expires_on"`
+	SignedOn        time.Time              `json:"signed_on"`
+	IssuedBy        Identity               `json:"issued_by"`
+	IssuedFor       string                 `json:"issued_for"`
+	Algorithm       Algorithm              `json:"algorithm"`
+	KeyID           string                 `json:"key_id"`
+	Signature       string                 `json:"signature"`
+	Message         string                 `json:"message"`
+	keyring         *Keyring               `json:"-"`
+	clock           func() time.Time       `json:"-"`
+	clockSkew       time.Duration          `json:"-"`
+	gracePeriod     time.Duration          `json:"-"`
+	warningPeriod   time.Duration          `json:"-"`
+	algorithms      []Algorithm            `json:"-"`
+	revocations     *RevocationList        `json:"-"`
+	onlineChecked   bool                   `json:"-"`
+	online          *OnlineCheck           `json:"-"`
+	onlineErr       error                  `json:"-"`
+	hostFingerprint string                 `json:"-"`
+	fingerprintErr  error                  `json:"-"`
+	activation      *Activation            `json:"-"`
+	activationErr   error                  `json:"-"`
 }
 
-func New(license string, options map[string]interface{}) (*License, error) {
-	if options == nil {
-		options = map[string]interface{}{}
+// DefaultClockSkew is the tolerance applied when checking "Signed on" and "Expires on" against current time
+const DefaultClockSkew = 5 * time.Minute
+
+// New loads the license (an URL, a path or the license content itself) and its public key, ready to be validated
+// Invalid options are reported here (ErrInvalidOption) instead of failing later
+func New(license string, opts ...Option) (*License, error) {
+	return NewWithContext(context.Background(), license, opts...)
+}
+
+// NewWithContext is New with a context carrying cancellation and deadline of the fetches
+func NewWithContext(ctx context.Context, license string, opts ...Option) (*License, error) {
+	if license == "" {
+		return nil, newError(ErrInvalidOption, nil, "Empty license")
+	}
+	return NewFromSource(ctx, GuessSource(license), opts...)
+}
+
+// NewFromSource loads the license from an explicit source (FromFile, FromURL, FromEnv, ... or a custom one)
+func NewFromSource(ctx context.Context, source LicenseSource, opts ...Option) (*License, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
 	}
-	if options["PublicKey"] == nil {
-		options["PublicKey"] = "https://buy.bmint.studio/api/v1/service/microservice/licensor/key"
+	if source == nil {
+		return nil, newError(ErrInvalidOption, nil, "Nil license source")
 	}
-	byteLicense, err := parseArgument(license, options)
+	byteLicense, err := readLicense(ctx, source, o)
 	if err != nil {
 		return nil, errors.Wrap(err, `Unable to parse license`)
 	}
-	bytePublicKey, err := parseArgument(options["PublicKey"].(string), options)
+	keyring, err := loadKeyring(ctx, o)
 	if err != nil {
-		return nil, errors.Wrap(err, `Unable to parse public key`)
+		return nil, err
 	}
-	signature, message, meta, err := extractLicenseData(byteLicense, bytePublicKey)
+	revocations, err := loadRevocationList(ctx, o, keyring, false)
 	if err != nil {
-		return nil, errors.Wrap(err, `Unable to extract license data`)
+		return nil, err
 	}
-	// Converting PublicKey
-	publicKey, err := convertPublicKey(bytePublicKey)
+	parsed, err := extractLicenseData(byteLicense)
 	if err != nil {
-		return nil, errors.Wrap(err, `Unable to convert public key`)
+		return nil, errors.Wrap(err, `Unable to extract license data`)
 	}
-	return &License{
-		Signature: signature,
-		Message:   message,
-		Meta:      meta,
-		publicKey: publicKey,
-	}, nil
+	parsed.keyring = keyring
+	parsed.revocations = revocations
+	// Fingerprinting the machine for node-locked and seat-limited licenses
+	_, bound := parsed.Meta[MetaFingerprint]
+	_, limited := parsed.Meta[MetaMaxActivations]
+	if bound || limited {
+		parsed.hostFingerprint, parsed.fingerprintErr = hostFingerprint(o)
+	}
+	// Reading the activation of seat-limited licenses, its failure is reported by Validate
+	if limited {
+		parsed.activation, parsed.activationErr = loadActivation(o, keyring, parsed.Serial)
+	}
+	// Querying the live status, its failure is reported by Validate along with the offline checks
+	if o.statusURL != "" {
+		parsed.onlineChecked = true
+		parsed.online, parsed.onlineErr = checkOnline(ctx, parsed.Serial, o)
+	}
+	// Configuring the validity window check
+	parsed.clock = o.clock
+	parsed.clockSkew = o.clockSkew
+	parsed.gracePeriod = o.gracePeriod
+	parsed.warningPeriod = o.warningPeriod
+	parsed.algorithms = o.allowedAlgorithms
+	return parsed, nil
 }
 
-// Validating if desired serial/metas are contained in current license
-// See this simple explanation if you wish to understand what's happening: https://www.sohamkamani.com/golang/rsa-encryption/#signing-and-verification
-func (t *License) Validate(meta map[string]interface{}) (bool, error) {
-	logger.Debug("Verifying...\n\n\t.::Message::.\n\n%s\n\n\t.::Signature::.\n\n%s", t.Message, t.Signature)
-	// Building verifiable message
-	msgHash := sha256.New()
-	_, err := msgHash.Write([]byte(t.Message))
-	if err != nil {
-		return false, errors.Wrap(err, `Unable to hash verifiable message`)
+// Parse reads the headers of a license without loading any key: its signature is not verified,
+// so that Validate fails with ErrInvalidKey; use it to display a license, never to trust it
+func Parse(license []byte) (*License, error) {
+	if len(license) == 0 {
+		return nil, newError(ErrInvalidFormat, nil, "Empty license")
 	}
-	msgHashSum := msgHash.Sum(nil)
-	// Since signature is encoded into base64 we decode it
-	signature, err := base64.StdEncoding.DecodeString(t.Signature)
+	parsed, err := extractLicenseData(license)
 	if err != nil {
-		return false, errors.Wrap(err, `Unable to decode base54 signature`)
+		return nil, errors.Wrap(err, `Unable to extract license data`)
 	}
+	parsed.clockSkew = DefaultClockSkew
+	return parsed, nil
+}
+
+// SigningKey is the trusted key selected to verify the license signature (by its "Key ID" header)
+func (t *License) SigningKey() (TrustedKey, error) {
+	if t.keyring == nil {
+		return TrustedKey{}, newError(ErrInvalidKey, nil, "No key loaded to verify the license")
+	}
+	return t.keyring.Lookup(t.KeyID, t.signingTime(), t.now())
+}
+
+// The time the signing key must be trusted at: the "Signed on" date, so that licenses signed before a key
+// rotation keep validating (current time for licenses without "Signed on")
+func (t *License) signingTime() time.Time {
+	if t.SignedOn.IsZero() {
+		return t.now()
+	}
+	return t.SignedOn
+}
+
+// Validating if desired serial/metas are contained in current license and if it's inside its validity window
+// Every check is run (even after a failure) and reported into the result; the returned error is the first failure
+func (t *License) Validate(meta map[string]interface{}) (*ValidationResult, error) {
+	result := &ValidationResult{}
 	// Verifying signature
-	err = rsa.VerifyPKCS1v15(t.publicKey, crypto.SHA256, msgHashSum, signature)
-	if err != nil {
-		//return false, errors.New(`Unable to verify license`)
-		return false, errors.Wrap(err, `Unable to verify license`)
+	result.add(newCheck(CheckSignature, t.verifySignature()))
+	// Checking license is not revoked
+	if t.revocations != nil {
+		result.add(newCheck(CheckRevocation, t.revocations.check(t)))
 	}
+	// Checking the licensor API reports the license as active
+	if t.onlineChecked {
+		check := newCheck(CheckOnline, t.onlineErr)
+		check.Expected = OnlineActive
+		if t.online != nil {
+			check.Actual = t.online.Status
+		}
+		result.add(check)
+		result.Online = t.online
+	}
+	// Checking node-locked license is bound to this machine
+	if _, bound := t.Meta[MetaFingerprint]; bound {
+		result.add(newCheck(CheckFingerprint, t.checkFingerprint()))
+	}
+	// Checking seat-limited license is activated on this machine
+	if _, limited := t.Meta[MetaMaxActivations]; limited {
+		result.add(newCheck(CheckActivation, t.checkActivation()))
+		result.Activation = t.activation
+	}
+	// Checking license is not expired nor used before it was signed
+	result.add(newCheck(CheckExpiry, t.checkValidityWindow()))
+	result.Validity = t.Validity()
+	// Checking desired metadata (sorted to offer a stable result)
 	if meta != nil {
 		logger.Debug("Checking desired meta: %v against license meta: %v", meta, t.Meta)
-		for key, value := range meta {
-			logger.Debug("Checking %q: %v...", key, value)
-			if t.Meta[key] != value {
-				return false, errors.New(`Unable to verify the presence for metadata "` + key + `" into the license`)
-			}
+		keys := make([]string, 0, len(meta))
+		for key := range meta {
+			keys = append(keys, key)
 		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			logger.Debug("Checking %q: %v...", key, meta[key])
+			result.add(t.checkMeta(key, meta[key]))
+		}
+	}
+	if err := result.Err(); err != nil {
+		return result, err
 	}
 	logger.Info("License validated correctly")
-	return true, nil
+	return result, nil
+}
+
+// Verifying the license message against its signature, with the algorithm declared by the license
+// See this simple explanation if you wish to understand what's happening: https://www.sohamkamani.com/golang/rsa-encryption/#signing-and-verification
+func (t *License) verifySignature() error {
+	logger.Debug("Verifying (%s)...\n\n\t.::Message::.\n\n%s\n\n\t.::Signature::.\n\n%s", t.Algorithm, t.Message, t.Signature)
+	if err := checkAlgorithmAllowed(t.Algorithm, t.algorithms); err != nil {
+		return err
+	}
+	// Selecting the key which signed the license
+	key, err := t.SigningKey()
+	if err != nil {
+		return err
+	}
+	if key.Algorithm != "" && key.Algorithm != t.Algorithm {
+		return newError(ErrInvalidSignature, nil, "Key ID %q is restricted to algorithm %s, got %s", key.ID, key.Algorithm, t.Algorithm)
+	}
+	// Since signature is encoded into base64 we decode it
+	signature, err := base64.StdEncoding.DecodeString(t.Signature)
+	if err != nil {
+		return newError(ErrInvalidSignature, err, `Unable to decode base64 signature`)
+	}
+	return verify(t.Algorithm, key.Key, []byte(t.Message), signature)
 }
 
-// Verifiyng license data and Extracting serial and meta
-func extractLicenseData(license []byte, publicKey []byte) (string, string, map[string]interface{}, error) {
-	logger.Debug("Extracting data from:\n\n\t.::License::.\n\n%s\n\n\t.::Public Key::.\n\n%s", license, publicKey)
+// Checking the presence of a desired metadata into the license
+// The key may be a dotted path into nested metadata and the value a Requirement (equality otherwise)
+func (t *License) checkMeta(key string, value interface{}) Check {
+	actual, found := lookupMeta(t.Meta, key)
+	return newMetaCheck(key, value, actual, found)
+}
+
+// Building the check of a metadata against its expected value (a Requirement, equality otherwise)
+func newMetaCheck(key string, value interface{}, actual interface{}, found bool) Check {
+	check := Check{
+		Name:     CheckMetaPrefix + key,
+		Passed:   true,
+		Expected: value,
+		Actual:   actual,
+	}
+	requirement, ok := value.(Requirement)
+	if !ok {
+		requirement = Equal(value)
+	}
+	if !found {
+		check.fail(&MetadataMismatchError{Key: key, Expected: value, Missing: true})
+	} else if err := requirement.Match(actual); err != nil {
+		check.fail(&MetadataMismatchError{Key: key, Expected: value, Actual: actual, Reason: err.Error()})
+	}
+	return check
+}
+
+// Verifiyng license data and Extracting signature, message and headers
+func extractLicenseData(license []byte) (*License, error) {
+	logger.Debug("Extracting data from:\n\n\t.::License::.\n\n%s", license)
 	// Getting the signature from license
 	reSignature := regexp.MustCompile(`(?s)====BEGIN SIGNATURE====(.*)====END SIGNATURE====`)
 	signatureMatches := reSignature.FindStringSubmatch(string(license))
 	if len(signatureMatches) != 2 {
-		return "", "", nil, errors.New(`Invalid license signature format`)
+		return nil, newError(ErrInvalidFormat, nil, `Invalid license signature format`)
 	}
 	signature := strings.Trim(signatureMatches[1], "\n")
 	// Getting the message from license
 	reMessage := regexp.MustCompile(`(?s)====BEGIN LICENSE====(.*)=====END LICENSE=====`)
 	messageMatches := reMessage.FindStringSubmatch(string(license))
 	if len(messageMatches) != 2 {
-		return signature, "", nil, errors.New(`Invalid license message format`)
+		return nil, newError(ErrInvalidFormat, nil, `Invalid license message format`)
 	}
 	message := strings.Trim(messageMatches[0], "\n")
-	// Getting the metadata from license
-	reMetadata := regexp.MustCompile(`Metadata: (.*)`)
-	metadataMatches := reMetadata.FindStringSubmatch(string(license))
-	if len(messageMatches) != 2 {
-		return signature, "", nil, errors.New(`Invalid license metadata format`)
+	// Getting the headers (serial, metadata, dates, ...) from license
+	extracted := &License{
+		Signature: signature,
+		Message:   message,
 	}
-	metadataToParse := strings.Trim(metadataMatches[1], "\n")
-	var metadata map[string]interface{}
-	if err := json.Unmarshal([]byte(metadataToParse), &metadata); err != nil {
-		return signature, message, nil, errors.Wrap(err, "Unable to parse metadata from license")
+	if err := parseHeaders(messageMatches[1], extracted); err != nil {
+		return nil, newError(ErrInvalidFormat, err, "Unable to parse headers from license")
 	}
 	// Offering extracted data from license
-	return signature, message, metadata, nil
-}
-
-func parseArgument(arg string, options map[string]interface{}) ([]byte, error) {
-	if isURL(arg) {
-		_, content, _, err := rest.Get(arg, map[string]string{
-			"Authorization": "Bearer " + options["Token"].(string),
-		}, options)
-		return content, err
-	}
-	if isPath(arg) {
-		return os.ReadFile(arg)
-	}
-	// Otherwise arg is a string
-	return []byte(arg), nil
+	return extracted, nil
 }
 
-// Checking if a string is an URL
-func isURL(stringToCheck string) bool {
-	_, err := url.ParseRequestURI(stringToCheck)
-	if err != nil {
-		return false
+// Converting a PEM (PKIX) public key: RSA, ECDSA (P-256 and P-384) and Ed25519 keys are supported
+func convertPublicKey(key []byte) (crypto.PublicKey, error) {
+	data, _ := pem.Decode(key)
+	if data == nil {
+		return nil, newError(ErrInvalidKey, nil, `Unable to decode public key; ensure the URL/path/string is correct`)
 	}
-	return true
-}
-
-// Checking if string is a valid path
-func isPath(stringToCheck string) bool {
-	_, err := os.Stat(stringToCheck)
+	parsedKey, err := x509.ParsePKIXPublicKey(data.Bytes)
 	if err != nil {
-		return false
+		return nil, newError(ErrInvalidKey, err, `Unable to parse public key by x509`)
 	}
-	return true
-}
-
-func convertPublicKey(key []byte) (*rsa.PublicKey, error) {
-	data, _ := pem.Decode(key)
-	if data == nil {
-		return nil, errors.New(`Unable to decode RSA public key; ensure the URL/path/string is correct`)
+	if err := checkKeySupported(parsedKey); err != nil {
+		return nil, err
 	}
-	var parsedKey *rsa.PublicKey
-	keyInterface, _ := x509.ParsePKIXPublicKey(data.Bytes)
-	parsedKey = keyInterface.(*rsa.PublicKey)
 	return parsedKey, nil
 }
 
-func convertPrivateKey(key []byte) (*rsa.PrivateKey, error) {
+func convertPrivateKey(key []byte, passphrase []byte) (crypto.Signer, error) {
 	data, _ := pem.Decode(key)
 	if data == nil {
-		return nil, errors.New(`Unable to decode RSA private key; ensure the URL/path/string is correct`)
+		return nil, newError(ErrInvalidKey, nil, `Unable to decode private key; ensure the URL/path/string is correct`)
+	}
+	der := data.Bytes
+	// Legacy PEM encryption (RFC 1423) is insecure (MD5 key derivation, no integrity check)
+	if _, legacy := data.Headers["DEK-Info"]; legacy {
+		return nil, newError(ErrInvalidKey, nil, `Legacy PEM encryption of private key is not supported, convert it to encrypted PKCS #8 (Eg: openssl pkcs8 -topk8 -v2 aes-256-cbc)`)
+	}
+	if data.Type == encryptedPrivateKeyType {
+		if len(passphrase) == 0 {
+			return nil, newError(ErrInvalidKey, nil, `Private key is encrypted but no passphrase was given`)
+		}
+		decrypted, err := decryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, newError(ErrInvalidKey, err, `Unable to decrypt private key`)
+		}
+		der = decrypted
+	}
+	var parsedKey interface{}
+	var err error
+	switch data.Type {
+	case "RSA PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		parsedKey, err = x509.ParseECPrivateKey(der)
+	default:
+		parsedKey, err = x509.ParsePKCS8PrivateKey(der)
 	}
-	parsedKey, err := x509.ParsePKCS1PrivateKey(data.Bytes)
 	if err != nil {
-		return nil, errors.Wrap(err, `Unable to parse RSA private key by x509`)
+		return nil, newError(ErrInvalidKey, err, `Unable to parse private key by x509`)
 	}
-	return parsedKey, nil
+	signer, ok := parsedKey.(crypto.Signer)
+	if !ok {
+		return nil, newError(ErrInvalidKey, nil, "Unsupported private key %T", parsedKey)
+	}
+	if err := checkKeySupported(signer.Public()); err != nil {
+		return nil, err
+	}
+	return signer, nil
 }
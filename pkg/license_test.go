@@ -3,10 +3,21 @@ package license
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
 )
 
+// Returning a clock always pointing at the given date
+func fixedClock(date string) func() time.Time {
+	now, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		panic(err)
+	}
+	return func() time.Time { return now }
+}
+
 func TestValidate(t *testing.T) {
 	logger.LogInit(logger.PanicLevel, false)
 	// Reading license
@@ -15,9 +26,7 @@ func TestValidate(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Building new license
-	license, err := New(string(content), map[string]interface{}{
-		"PublicKey": "../test/assets/public.key",
-	})
+	license, err := New(string(content), WithPublicKey("../test/assets/public.key"), WithClock(fixedClock("2022-05-01T00:00:00Z")))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -41,3 +50,120 @@ func TestValidate(t *testing.T) {
 		t.Fatal("Validation should fail with a wrong metadata!")
 	}
 }
+
+func TestValidateWindow(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newLicense := func(opts ...Option) *License {
+		license, err := New(string(content), append(opts, WithPublicKey("../test/assets/public.key"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return license
+	}
+	// Expired license (Expires on: 2022-05-29T15:02:00Z)
+	_, err = newLicense(WithClock(fixedClock("2022-06-01T00:00:00Z"))).Validate(nil)
+	var expiredErr *ExpiredError
+	if !errors.As(err, &expiredErr) {
+		t.Errorf("Expected an ExpiredError, got %v", err)
+	}
+	// Not yet valid license (Signed on: 2022-04-29T15:03:37Z)
+	_, err = newLicense(WithClock(fixedClock("2022-04-01T00:00:00Z"))).Validate(nil)
+	var notYetValidErr *NotYetValidError
+	if !errors.As(err, &notYetValidErr) {
+		t.Errorf("Expected a NotYetValidError, got %v", err)
+	}
+	// Just expired license tolerated by the default clock skew
+	_, err = newLicense(WithClock(fixedClock("2022-05-29T15:03:00Z"))).Validate(nil)
+	if err != nil {
+		t.Errorf("Expected no error within clock skew, got %v", err)
+	}
+	// Just expired license rejected without clock skew
+	_, err = newLicense(WithClock(fixedClock("2022-05-29T15:03:00Z")), WithClockSkew(0)).Validate(nil)
+	if !errors.As(err, &expiredErr) {
+		t.Errorf("Expected an ExpiredError without clock skew, got %v", err)
+	}
+	// Invalid clock options
+	if _, err := New(string(content), WithPublicKey("../test/assets/public.key"), WithClock(nil)); err == nil {
+		t.Error("Expected an error with a nil clock")
+	}
+	if _, err := New(string(content), WithPublicKey("../test/assets/public.key"), WithClockSkew(-time.Minute)); err == nil {
+		t.Error("Expected an error with a negative clock skew")
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := New(string(content), WithPublicKey("../test/assets/public.key"), WithClock(fixedClock("2022-05-01T00:00:00Z")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := license.Validate(map[string]interface{}{
+		"agency": "A144109",
+		"Alpha":  "string to test",
+		"Gamma":  true,
+	})
+	if err == nil || result.Valid {
+		t.Fatal("Validation should fail with a wrong metadata!")
+	}
+	// Every check is reported, in a stable order, with its own outcome
+	expected := []struct {
+		name   string
+		passed bool
+	}{
+		{CheckSignature, true},
+		{CheckExpiry, true},
+		{"meta.Alpha", false},
+		{"meta.Gamma", false},
+		{"meta.agency", true},
+	}
+	if len(result.Checks) != len(expected) {
+		t.Fatalf("Expected %d checks, got %+v", len(expected), result.Checks)
+	}
+	for i, check := range result.Checks {
+		if check.Name != expected[i].name || check.Passed != expected[i].passed {
+			t.Errorf("Check %d: got %s (passed: %t), want %s (passed: %t)", i, check.Name, check.Passed, expected[i].name, expected[i].passed)
+		}
+		if !check.Passed && check.Reason == "" {
+			t.Errorf("Check %s failed without a reason", check.Name)
+		}
+	}
+	if len(result.Failed()) != 2 {
+		t.Errorf("Expected 2 failed checks, got %+v", result.Failed())
+	}
+	if result.Checks[4].Expected != "A144109" || result.Checks[4].Actual != "A144109" {
+		t.Errorf("Expected/actual values not reported: %+v", result.Checks[4])
+	}
+}
+
+func TestParse(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := Parse(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if license.Serial != "foo-test-alpha" {
+		t.Errorf("Unexpected serial %q", license.Serial)
+	}
+	// No key is loaded: the signature can't be trusted
+	if _, err := license.SigningKey(); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := Parse(nil); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat, got %v", err)
+	}
+}
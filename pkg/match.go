@@ -0,0 +1,369 @@
+package license
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Operator of a metadata requirement
+type Operator string
+
+// Supported operators of metadata requirements
+const (
+	OpEqual          Operator = "="
+	OpNotEqual       Operator = "!="
+	OpGreater        Operator = ">"
+	OpGreaterOrEqual Operator = ">="
+	OpLess           Operator = "<"
+	OpLessOrEqual    Operator = "<="
+	OpContains       Operator = "contains"
+	OpMatches        Operator = "~"
+	OpAll            Operator = "all"
+)
+
+// Requirement is a condition on a metadata value, given as value of the meta map of Validate
+// (plain values are required to be equal, several conditions on the same key are combined with All).
+// Numbers are compared whatever their Go type (Eg: 1 equals 1.0), dates are RFC 3339 strings (or "2006-01-02")
+// and time.Time values
+type Requirement struct {
+	Operator Operator
+	Value    interface{}
+	// Text of the value into the parsed expression, also matched by string metadata (Eg: "123" for "agency=123")
+	raw string
+}
+
+// Equal requires the metadata to be equal to the value
+func Equal(value interface{}) Requirement {
+	return Requirement{Operator: OpEqual, Value: value}
+}
+
+// NotEqual requires the metadata to be different from the value
+func NotEqual(value interface{}) Requirement {
+	return Requirement{Operator: OpNotEqual, Value: value}
+}
+
+// Greater requires the metadata (number or date) to be greater than the value
+func Greater(value interface{}) Requirement {
+	return Requirement{Operator: OpGreater, Value: value}
+}
+
+// GreaterOrEqual requires the metadata (number or date) to be greater than or equal to the value
+func GreaterOrEqual(value interface{}) Requirement {
+	return Requirement{Operator: OpGreaterOrEqual, Value: value}
+}
+
+// Less requires the metadata (number or date) to be less than the value
+func Less(value interface{}) Requirement {
+	return Requirement{Operator: OpLess, Value: value}
+}
+
+// LessOrEqual requires the metadata (number or date) to be less than or equal to the value
+func LessOrEqual(value interface{}) Requirement {
+	return Requirement{Operator: OpLessOrEqual, Value: value}
+}
+
+// Contains requires the metadata (array) to hold the value, or the metadata (string) to contain the value (string)
+func Contains(value interface{}) Requirement {
+	return Requirement{Operator: OpContains, Value: value}
+}
+
+// Matches requires the metadata (string, number or boolean) to match the regular expression
+func Matches(pattern string) Requirement {
+	return Requirement{Operator: OpMatches, Value: pattern}
+}
+
+// All requires the metadata to satisfy every requirement (Eg: a range, All(GreaterOrEqual(10), LessOrEqual(20)))
+func All(requirements ...Requirement) Requirement {
+	all := make([]Requirement, 0, len(requirements))
+	for _, requirement := range requirements {
+		if nested, ok := requirement.Value.([]Requirement); ok && requirement.Operator == OpAll {
+			all = append(all, nested...)
+			continue
+		}
+		all = append(all, requirement)
+	}
+	return Requirement{Operator: OpAll, Value: all}
+}
+
+// String formats the requirement as an expression (without its path)
+func (r Requirement) String() string {
+	value := r.Value
+	if r.raw != "" {
+		// Keeping the text of the parsed expression (Eg: "1.10" rather than 1.1)
+		value = r.raw
+	}
+	switch r.Operator {
+	case OpContains:
+		return fmt.Sprintf("contains %v", value)
+	case OpAll:
+		requirements, _ := r.Value.([]Requirement)
+		expressions := make([]string, 0, len(requirements))
+		for _, requirement := range requirements {
+			expressions = append(expressions, requirement.String())
+		}
+		return strings.Join(expressions, " and ")
+	}
+	return fmt.Sprintf("%s %v", r.Operator, value)
+}
+
+// MarshalJSON writes the requirement as its expression
+func (r Requirement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// MarshalYAML writes the requirement as its expression
+func (r Requirement) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+// Match checks the actual metadata value against the requirement; the error describes the mismatch
+func (r Requirement) Match(actual interface{}) error {
+	switch r.Operator {
+	case OpEqual:
+		if !r.equals(actual) {
+			return errors.Errorf("expected %v, got %v", r.Value, actual)
+		}
+	case OpNotEqual:
+		if r.equals(actual) {
+			return errors.Errorf("expected a value different from %v", r.Value)
+		}
+	case OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual:
+		comparison, err := compareValues(actual, r.Value)
+		if err != nil {
+			return err
+		}
+		satisfied := map[Operator]bool{
+			OpGreater:        comparison > 0,
+			OpGreaterOrEqual: comparison >= 0,
+			OpLess:           comparison < 0,
+			OpLessOrEqual:    comparison <= 0,
+		}[r.Operator]
+		if !satisfied {
+			return errors.Errorf("expected %s, got %v", r, actual)
+		}
+	case OpContains:
+		if !containsValue(actual, r.Value) && (r.raw == "" || !containsValue(actual, r.raw)) {
+			return errors.Errorf("expected %s, got %v", r, actual)
+		}
+	case OpMatches:
+		pattern, ok := r.Value.(string)
+		if !ok {
+			return errors.Errorf("invalid regular expression %v", r.Value)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrapf(err, "invalid regular expression %q", pattern)
+		}
+		switch actual.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			return errors.Errorf("expected a value matching %q, got %v", pattern, actual)
+		}
+		if !re.MatchString(fmt.Sprint(actual)) {
+			return errors.Errorf("expected a value matching %q, got %v", pattern, actual)
+		}
+	case OpAll:
+		requirements, ok := r.Value.([]Requirement)
+		if !ok {
+			return errors.Errorf("invalid requirements %v", r.Value)
+		}
+		for _, requirement := range requirements {
+			if err := requirement.Match(actual); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unsupported operator %q", r.Operator)
+	}
+	return nil
+}
+
+// Comparing equality with the value or, for string metadata, with the text of the parsed expression value
+// (an expression value is read as JSON, so "version=1.10" would never equal the string "1.10" otherwise)
+func (r Requirement) equals(actual interface{}) bool {
+	if equalValues(actual, r.Value) {
+		return true
+	}
+	text, ok := actual.(string)
+	return ok && r.raw != "" && text == r.raw
+}
+
+// Expression operators, longest first so that ">=" is not read as ">"
+var reRequirement = regexp.MustCompile(`^\s*([^\s=!<>~]+)\s*(?:(>=|<=|!=|==|=|>|<|~)|\s(contains)\s)\s*(.*?)\s*$`)
+
+// ParseRequirement parses a metadata requirement expression "path operator value" (Eg: "seats>=10",
+// "customer.region~^eu-", "features contains sso"): path is a dotted path into nested metadata (array items by index)
+// and value is read as JSON when possible (Eg: 10, true, "10"), as a raw string otherwise. String metadata also
+// match the text of the value (Eg: "version=1.10" matches "1.10", which the number 1.1 doesn't)
+func ParseRequirement(expression string) (string, Requirement, error) {
+	matches := reRequirement.FindStringSubmatch(expression)
+	if matches == nil {
+		return "", Requirement{}, newError(ErrInvalidOption, nil, "Invalid requirement %q (expected: path operator value)", expression)
+	}
+	path, operator, raw := matches[1], Operator(matches[2]+matches[3]), matches[4]
+	if operator == "==" {
+		operator = OpEqual
+	}
+	if operator == OpMatches {
+		if _, err := regexp.Compile(raw); err != nil {
+			return "", Requirement{}, newError(ErrInvalidOption, err, "Invalid regular expression into %q", expression)
+		}
+		return path, Matches(raw), nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	return path, Requirement{Operator: operator, Value: value, raw: raw}, nil
+}
+
+// Looking up a metadata by key or, otherwise, by dotted path into nested objects and arrays (Eg: "limits.seats", "tags.0")
+func lookupMeta(meta map[string]interface{}, path string) (interface{}, bool) {
+	if value, found := meta[path]; found {
+		return value, true
+	}
+	var current interface{} = meta
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, found := node[segment]
+			if !found {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Converting any Go number (or JSON number) to float64
+func toNumber(value interface{}) (float64, bool) {
+	if number, ok := value.(json.Number); ok {
+		parsed, err := number.Float64()
+		return parsed, err == nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// Converting a date (time.Time, RFC 3339 or "2006-01-02" string)
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if date, err := time.Parse(layout, v); err == nil {
+				return date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Comparing equality, numbers whatever their type, arrays and objects item by item
+func equalValues(actual, expected interface{}) bool {
+	if actualNumber, ok := toNumber(actual); ok {
+		expectedNumber, ok := toNumber(expected)
+		return ok && actualNumber == expectedNumber
+	}
+	a, e := reflect.ValueOf(actual), reflect.ValueOf(expected)
+	if !a.IsValid() || !e.IsValid() {
+		return !a.IsValid() && !e.IsValid()
+	}
+	switch {
+	case (a.Kind() == reflect.Slice || a.Kind() == reflect.Array) && (e.Kind() == reflect.Slice || e.Kind() == reflect.Array):
+		if a.Len() != e.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i).Interface(), e.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	case a.Kind() == reflect.Map && e.Kind() == reflect.Map:
+		if a.Len() != e.Len() {
+			return false
+		}
+		for _, key := range e.MapKeys() {
+			actualValue := a.MapIndex(key)
+			if !actualValue.IsValid() || !equalValues(actualValue.Interface(), e.MapIndex(key).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// Comparing numbers or dates: negative when actual is less than expected, positive when greater
+func compareValues(actual, expected interface{}) (int, error) {
+	if expectedNumber, ok := toNumber(expected); ok {
+		actualNumber, ok := toNumber(actual)
+		if !ok {
+			return 0, errors.Errorf("expected a number, got %v", actual)
+		}
+		switch {
+		case actualNumber < expectedNumber:
+			return -1, nil
+		case actualNumber > expectedNumber:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	if expectedDate, ok := toTime(expected); ok {
+		actualDate, ok := toTime(actual)
+		if !ok {
+			return 0, errors.Errorf("expected a date, got %v", actual)
+		}
+		switch {
+		case actualDate.Before(expectedDate):
+			return -1, nil
+		case actualDate.After(expectedDate):
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, errors.Errorf("%v is neither a number nor a date", expected)
+}
+
+// Checking membership into an array (or a substring into a string)
+func containsValue(actual, expected interface{}) bool {
+	if actualString, ok := actual.(string); ok {
+		expectedString, ok := expected.(string)
+		return ok && strings.Contains(actualString, expectedString)
+	}
+	a := reflect.ValueOf(actual)
+	if a.Kind() != reflect.Slice && a.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < a.Len(); i++ {
+		if equalValues(a.Index(i).Interface(), expected) {
+			return true
+		}
+	}
+	return false
+}
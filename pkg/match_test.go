@@ -0,0 +1,107 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestValidateRequirements(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := `{"agency":"A144109","code":"123","version":"1.10","editions":["2022","2023"],"seats":10,"renewal":"2023-01-01T00:00:00Z","features":["sso","audit"],"customer":{"region":"eu-west","limits":{"projects":5}}}`
+	content := testSignLicense(t, EdDSA, privateKey, "Serial: foo-match\nAlgorithm: EdDSA\nMetadata: "+metadata)
+	license, err := New(content, WithPublicKey(testPublicKeyPEM(t, publicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	satisfied := map[string]interface{}{
+		"seats":                    10, // Numbers are equal whatever their type
+		"customer.region":          "eu-west",
+		"customer.limits.projects": GreaterOrEqual(int64(5)),
+		"features.0":               "sso",
+		"features":                 Contains("audit"),
+		"renewal":                  LessOrEqual(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)),
+		"agency":                   Matches(`^A\d+$`),
+		"customer":                 map[string]interface{}{"region": "eu-west", "limits": map[string]interface{}{"projects": 5}},
+	}
+	if result, err := license.Validate(satisfied); err != nil {
+		t.Errorf("Expected satisfied requirements, got %v (%+v)", err, result.Failed())
+	}
+	// String metadata equal to the text of the expression values, read as numbers
+	for _, expression := range []string{"code=123", "version=1.10", "seats=10", "seats=10.0", "editions contains 2023", "version!=1.1"} {
+		path, requirement, err := ParseRequirement(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result, err := license.Validate(map[string]interface{}{path: requirement}); err != nil {
+			t.Errorf("%s: expected a satisfied requirement, got %v (%+v)", expression, err, result.Failed())
+		}
+	}
+	for _, expression := range []string{"code=124", "version=1.1", "code!=123", "editions contains 2024"} {
+		path, requirement, err := ParseRequirement(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := license.Validate(map[string]interface{}{path: requirement}); !errors.Is(err, ErrMetadataMismatch) {
+			t.Errorf("%s: expected ErrMetadataMismatch, got %v", expression, err)
+		}
+	}
+	unsatisfied := map[string]interface{}{
+		"seats":                    Greater(10),
+		"customer.limits.projects": LessOrEqual(4),
+		"features":                 Contains("billing"),
+		"renewal":                  GreaterOrEqual("2023-06-01"),
+		"agency":                   Matches(`^B`),
+		"customer.country":         "FR",
+		"features.5":               "sso",
+		"customer.region":          NotEqual("eu-west"),
+		"agency.code":              LessOrEqual(1),
+	}
+	result, err := license.Validate(unsatisfied)
+	if !errors.Is(err, ErrMetadataMismatch) {
+		t.Fatalf("Expected ErrMetadataMismatch, got %v", err)
+	}
+	if len(result.Failed()) != len(unsatisfied) {
+		t.Errorf("Expected %d failed checks, got %+v", len(unsatisfied), result.Failed())
+	}
+}
+
+func TestParseRequirement(t *testing.T) {
+	expressions := map[string]struct {
+		path        string
+		requirement Requirement
+	}{
+		"agency=A144109":           {"agency", Equal("A144109")},
+		"seats == 10":              {"seats", Equal(float64(10))},
+		"seats>=10":                {"seats", GreaterOrEqual(float64(10))},
+		"renewal <= 2023-06-01":    {"renewal", LessOrEqual("2023-06-01")},
+		"trial!=true":              {"trial", NotEqual(true)},
+		"code=\"10\"":              {"code", Equal("10")},
+		"features contains sso":    {"features", Contains("sso")},
+		"customer.region~^eu-":     {"customer.region", Matches("^eu-")},
+		"customer.limits.users<50": {"customer.limits.users", Less(float64(50))},
+	}
+	for expression, expected := range expressions {
+		path, requirement, err := ParseRequirement(expression)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if path != expected.path || requirement.Operator != expected.requirement.Operator || requirement.Value != expected.requirement.Value {
+			t.Errorf("%s: got %q %+v, want %q %+v", expression, path, requirement, expected.path, expected.requirement)
+		}
+	}
+	for _, expression := range []string{"", "agency", "=A144109", "agency~(["} {
+		if _, _, err := ParseRequirement(expression); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%q: expected ErrInvalidOption, got %v", expression, err)
+		}
+	}
+}
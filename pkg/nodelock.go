@@ -0,0 +1,68 @@
+package license
+
+import (
+	"strings"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/pkg/fingerprint"
+	"github.com/pkg/errors"
+)
+
+// MetaFingerprint is the metadata binding a license to the machines with the given fingerprints (node-locked license):
+// a fingerprint or an array of fingerprints, computed with the fingerprint package (Eg: "buymint-cli fingerprint")
+const MetaFingerprint = "fingerprint"
+
+// AllowedFingerprints lists the fingerprints of the machines the license is bound to (none for a floating license)
+func (t *License) AllowedFingerprints() ([]string, error) {
+	value, found := t.Meta[MetaFingerprint]
+	if !found {
+		return nil, nil
+	}
+	var allowed []string
+	switch v := value.(type) {
+	case string:
+		allowed = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			fingerprint, ok := item.(string)
+			if !ok {
+				return nil, newError(ErrInvalidFormat, nil, "Invalid fingerprint %v into metadata %q", item, MetaFingerprint)
+			}
+			allowed = append(allowed, fingerprint)
+		}
+	default:
+		return nil, newError(ErrInvalidFormat, nil, "Invalid metadata %q (expected a fingerprint or an array of fingerprints)", MetaFingerprint)
+	}
+	if len(allowed) == 0 {
+		return nil, newError(ErrInvalidFormat, nil, "Empty metadata %q", MetaFingerprint)
+	}
+	return allowed, nil
+}
+
+// Computing the fingerprint of the current machine, unless given by WithHostFingerprint
+func hostFingerprint(o *options) (string, error) {
+	if o.hostFingerprint != "" {
+		return o.hostFingerprint, nil
+	}
+	id, err := fingerprint.Compute(o.fingerprintOptions...)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to compute the fingerprint of this machine")
+	}
+	return id, nil
+}
+
+// Checking the current machine is one of the machines the license is bound to
+func (t *License) checkFingerprint() error {
+	allowed, err := t.AllowedFingerprints()
+	if err != nil {
+		return err
+	}
+	if t.fingerprintErr != nil {
+		return t.fingerprintErr
+	}
+	for _, fingerprintID := range allowed {
+		if fingerprint.Equal(fingerprintID, t.hostFingerprint) {
+			return nil
+		}
+	}
+	return newError(ErrFingerprintMismatch, nil, "License is bound to %s, not to this machine (%s)", strings.Join(allowed, ", "), t.hostFingerprint)
+}
@@ -0,0 +1,65 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/pkg/fingerprint"
+	"github.com/pkg/errors"
+)
+
+func TestNodeLocked(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	validate := func(meta map[string]interface{}, opts ...Option) (*ValidationResult, error) {
+		content, err := Issue(License{Serial: "foo-node", Meta: meta, SignedOn: signedOn}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		license, err := New(content, append([]Option{WithPublicKey(string(publicKey)), WithClock(fixedClock("2022-06-01T00:00:00Z"))}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return license.Validate(nil)
+	}
+	bound := map[string]interface{}{MetaFingerprint: []interface{}{"aaaa", "bbbb"}}
+	if _, err := validate(bound, WithHostFingerprint("BBBB")); err != nil {
+		t.Errorf("Expected a license bound to this machine, got %v", err)
+	}
+	result, err := validate(bound, WithHostFingerprint("cccc"))
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("Expected a license bound to another machine, got %v", err)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].Name != CheckFingerprint {
+		t.Errorf("Expected the fingerprint check to fail, got %+v", failed)
+	}
+	// The fingerprint of the machine is computed with the given options
+	id, err := fingerprint.Compute(fingerprint.WithComponents(fingerprint.Hostname))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validate(map[string]interface{}{MetaFingerprint: id}, WithFingerprint(fingerprint.WithComponents(fingerprint.Hostname))); err != nil {
+		t.Errorf("Expected a license bound to this machine, got %v", err)
+	}
+	if _, err := validate(map[string]interface{}{MetaFingerprint: id}, WithFingerprint(fingerprint.WithComponents(fingerprint.Hostname), fingerprint.WithSalt([]byte("other")))); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("Expected a license bound to another machine, got %v", err)
+	}
+	// Malformed bindings are rejected, floating licenses are not checked
+	for _, value := range []interface{}{float64(1), []interface{}{}, []interface{}{"aaaa", float64(1)}} {
+		if _, err := validate(map[string]interface{}{MetaFingerprint: value}, WithHostFingerprint("aaaa")); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("Expected an invalid binding %v, got %v", value, err)
+		}
+	}
+	if result, err := validate(nil, WithHostFingerprint("cccc")); err != nil || len(result.Checks) != 2 {
+		t.Errorf("Expected a floating license, got %+v (%v)", result, err)
+	}
+}
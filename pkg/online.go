@@ -0,0 +1,169 @@
+package license
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// DefaultStatusURL is the BuyMint API endpoint of the live status of a license ("{serial}" is replaced by its serial)
+const DefaultStatusURL = "https://buy.bmint.studio/api/v1/service/microservice/licensor/license/{serial}/status"
+
+// OnlineStatus is the live status of a license reported by the licensor API
+type OnlineStatus string
+
+const (
+	// OnlineActive means the license is in use as sold
+	OnlineActive OnlineStatus = "active"
+	// OnlineSuspended means the licensor suspended the license
+	OnlineSuspended OnlineStatus = "suspended"
+	// OnlineRefunded means the license was refunded
+	OnlineRefunded OnlineStatus = "refunded"
+	// OnlineSubscriptionCancelled means the subscription of the license was cancelled
+	OnlineSubscriptionCancelled OnlineStatus = "subscription_cancelled"
+	// OnlineUnknown means the licensor API doesn't know the license
+	OnlineUnknown OnlineStatus = "unknown"
+)
+
+// OnlineCheck is the outcome of the online verification of a license
+type OnlineCheck struct {
+	Status    OnlineStatus `json:"status"`
+	CheckedOn time.Time    `json:"checked_on"`
+	// Offline means the licensor API was unreachable: the status is the one of the last online check (see WithOfflineGrace)
+	Offline bool `json:"offline"`
+}
+
+// InactiveError is returned when the licensor API reports the license is not active
+type InactiveError struct {
+	Serial string
+	Status OnlineStatus
+}
+
+func (e *InactiveError) Error() string {
+	return fmt.Sprintf("License %q is not active (status: %s)", e.Serial, e.Status)
+}
+
+// Is makes errors.Is(err, ErrInactive) work
+func (e *InactiveError) Is(target error) bool {
+	return target == ErrInactive
+}
+
+// Checking the reported status is active
+func (c *OnlineCheck) check(serial string) error {
+	if c.Status != OnlineActive {
+		return &InactiveError{Serial: serial, Status: c.Status}
+	}
+	return nil
+}
+
+// DefaultStatusCacheDir is the directory where the last online checks are kept (under the user cache directory)
+func DefaultStatusCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buymint-cli", "status"), nil
+}
+
+// statusCache keeps the last online check of every license on disk, used while the licensor API is unreachable
+// Its files are not signed, so it's not tamper-proof: whoever can write them can fake an active status for the
+// offline grace, which checks dated in the future can't extend
+type statusCache struct {
+	dir   string
+	grace time.Duration
+}
+
+// statusCacheEntry is the content of a cache file
+type statusCacheEntry struct {
+	URL       string       `json:"url"`
+	Status    OnlineStatus `json:"status"`
+	CheckedOn time.Time    `json:"checked_on"`
+}
+
+// Path of the cache file of a status URL
+func (c *statusCache) path(URL string) string {
+	sum := sha256.Sum256([]byte(URL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Reading the cache entry of a status URL (nil if missing or unreadable)
+func (c *statusCache) read(URL string) *statusCacheEntry {
+	content, err := os.ReadFile(c.path(URL))
+	if err != nil {
+		return nil
+	}
+	var entry statusCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.URL != URL {
+		logger.Warn("Ignoring corrupted status cache entry for %q", URL)
+		return nil
+	}
+	return &entry
+}
+
+// Writing the cache entry of a status URL
+func (c *statusCache) write(entry *statusCacheEntry) error {
+	return writeCacheFile(c.dir, c.path(entry.URL), entry)
+}
+
+// Querying the licensor API for the live status of the license; when the API is unreachable (network failure or
+// server error), the last online check is used within the offline grace window
+func checkOnline(ctx context.Context, serial string, o *options) (*OnlineCheck, error) {
+	URL := strings.ReplaceAll(o.statusURL, "{serial}", url.PathEscape(serial))
+	now := o.clock()
+	status, content, _, err := FromURL(URL).withOptions(o).fetchConditional(ctx, "")
+	var online *OnlineCheck
+	switch {
+	case err == nil:
+		var response struct {
+			Status OnlineStatus `json:"status"`
+		}
+		if err := json.Unmarshal(content, &response); err != nil || response.Status == "" {
+			return nil, newError(ErrInvalidFormat, err, "Unable to parse the status of license %q", serial)
+		}
+		online = &OnlineCheck{Status: response.Status, CheckedOn: now}
+	case status == http.StatusNotFound:
+		online = &OnlineCheck{Status: OnlineUnknown, CheckedOn: now}
+	case status >= 400 && status < 500:
+		// The API answered: refusing the request (Eg: wrong token) is not an outage
+		return nil, err
+	default:
+		return offlineCheck(serial, URL, now, o, err)
+	}
+	if o.statusCache != nil {
+		if err := o.statusCache.write(&statusCacheEntry{URL: URL, Status: online.Status, CheckedOn: now}); err != nil {
+			logger.Warn("Unable to write status cache: %s", err)
+		}
+	}
+	return online, online.check(serial)
+}
+
+// Falling back to the last online check, as long as it's within the offline grace window
+func offlineCheck(serial string, URL string, now time.Time, o *options, err error) (*OnlineCheck, error) {
+	if o.statusCache == nil {
+		return nil, errors.Wrap(err, "Unable to check license online")
+	}
+	entry := o.statusCache.read(URL)
+	if entry == nil {
+		return nil, errors.Wrap(err, "Unable to check license online (never checked online before)")
+	}
+	if entry.CheckedOn.After(now.Add(o.clockSkew)) {
+		return nil, newError(ErrFetch, err, "Unable to check license online (the last online check is dated in the future: %s)", entry.CheckedOn.Format(time.RFC3339))
+	}
+	if now.Sub(entry.CheckedOn) > o.statusCache.grace {
+		return nil, newError(ErrFetch, err, "Unable to check license online since %s (offline grace of %s exceeded)", entry.CheckedOn.Format(time.RFC3339), o.statusCache.grace)
+	}
+	logger.Warn("Licensor API unreachable, using the status of license %q checked online on %s: %s", serial, entry.CheckedOn.Format(time.RFC3339), err)
+	online := &OnlineCheck{Status: entry.Status, CheckedOn: entry.CheckedOn, Offline: true}
+	return online, online.check(serial)
+}
@@ -0,0 +1,124 @@
+package license
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestOnlineCheck(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	issue := func(serial string) string {
+		content, err := Issue(License{Serial: serial, SignedOn: signedOn, ExpiresOn: signedOn.AddDate(1, 0, 0)}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	// Licensor API stand-in reporting the status of known serials
+	var mutex sync.Mutex
+	statuses := map[string]string{"foo-active": "active", "foo-suspended": "suspended", "foo-refunded": "refunded"}
+	online, token := true, ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		token = r.Header.Get("Authorization")
+		if !online {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		status, found := statuses[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/licenses/"), "/status")]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"serial": "ignored", "status": "` + status + `"}`))
+	}))
+	defer server.Close()
+	setOnline := func(up bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		online = up
+	}
+	dir := t.TempDir()
+	now := signedOn.AddDate(0, 1, 0)
+	validate := func(serial string, opts ...Option) (*ValidationResult, error) {
+		license, err := New(issue(serial), append([]Option{
+			WithPublicKey(string(publicKey)),
+			WithOnlineCheck(server.URL + "/licenses/{serial}/status"),
+			WithToken("secret"),
+			WithClock(func() time.Time { return now }),
+		}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return license.Validate(nil)
+	}
+	// The live status is combined with the offline checks
+	result, err := validate("foo-active", WithOfflineGrace(dir, 72*time.Hour))
+	if err != nil || result.Online == nil || result.Online.Status != OnlineActive || result.Online.Offline {
+		t.Errorf("Expected an active license, got %+v (%v)", result.Online, err)
+	}
+	mutex.Lock()
+	if token != "Bearer secret" {
+		t.Errorf("Expected the token to be sent, got %q", token)
+	}
+	mutex.Unlock()
+	for serial, status := range map[string]OnlineStatus{"foo-suspended": OnlineSuspended, "foo-refunded": OnlineRefunded, "foo-unknown": OnlineUnknown} {
+		result, err := validate(serial)
+		var inactiveErr *InactiveError
+		if !errors.Is(err, ErrInactive) || !errors.As(err, &inactiveErr) || inactiveErr.Status != status {
+			t.Errorf("%s: expected an inactive license (%s), got %v", serial, status, err)
+		}
+		if failed := result.Failed(); len(failed) != 1 || failed[0].Name != CheckOnline {
+			t.Errorf("%s: expected the online check to fail, got %+v", serial, failed)
+		}
+	}
+	// The API being unreachable, the last online check is used within the offline grace window
+	setOnline(false)
+	now = now.Add(24 * time.Hour)
+	result, err = validate("foo-active", WithOfflineGrace(dir, 72*time.Hour))
+	if err != nil || result.Online == nil || !result.Online.Offline {
+		t.Errorf("Expected an active license checked offline, got %+v (%v)", result.Online, err)
+	}
+	now = now.Add(72 * time.Hour)
+	if _, err := validate("foo-active", WithOfflineGrace(dir, 72*time.Hour)); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected the offline grace to be exceeded, got %v", err)
+	}
+	// A last online check dated in the future (Eg: a tampered cache) never extends the offline grace
+	cachePath := (&statusCache{dir: dir}).path(server.URL + "/licenses/foo-active/status")
+	if err := writeCacheFile(dir, cachePath, &statusCacheEntry{URL: server.URL + "/licenses/foo-active/status", Status: OnlineActive, CheckedOn: now.AddDate(10, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validate("foo-active", WithOfflineGrace(dir, 72*time.Hour)); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected a check dated in the future to be rejected, got %v", err)
+	}
+	// Without offline grace (or never checked online) the API must be reachable
+	if _, err := validate("foo-active"); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected a fetch error, got %v", err)
+	}
+	if _, err := validate("foo-other", WithOfflineGrace(dir, 72*time.Hour)); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected a fetch error, got %v", err)
+	}
+	// Invalid options
+	for _, opt := range []Option{WithOnlineCheck("https://licensor.test/status"), WithOnlineCheck("not an URL {serial}"), WithOfflineGrace(dir, 0)} {
+		if _, err := New(issue("foo-active"), WithPublicKey(string(publicKey)), opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Expected an invalid option, got %v", err)
+		}
+	}
+}
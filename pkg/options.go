@@ -0,0 +1,391 @@
+package license
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/rest"
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/pkg/fingerprint"
+)
+
+// DefaultPublicKey is the BuyMint licensor public key used when no other key is configured
+const DefaultPublicKey = "https://buy.bmint.studio/api/v1/service/microservice/licensor/key"
+
+// Option configures how a License is loaded and validated (see New)
+type Option func(*options) error
+
+type options struct {
+	keySource          KeySource
+	keyring            *Keyring
+	jwksSource         KeySource
+	keyCache           *keyCache
+	keyPins            []string
+	token              string
+	httpClient         *http.Client
+	insecureSkipVerify bool
+	timeout            time.Duration
+	clock              func() time.Time
+	clockSkew          time.Duration
+	gracePeriod        time.Duration
+	warningPeriod      time.Duration
+	allowedAlgorithms  []Algorithm
+	revocationSource   KeySource
+	revocationList     *RevocationList
+	revocationCache    *revocationCache
+	revocationGrace    time.Duration
+	statusURL          string
+	statusCache        *statusCache
+	fingerprintOptions []fingerprint.Option
+	hostFingerprint    string
+	activationURL      string
+	activationStore    *activationStore
+}
+
+// Building the options with their default values and applying the desired ones
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		keySource:     FromURL(DefaultPublicKey),
+		clock:         time.Now,
+		clockSkew:     DefaultClockSkew,
+		timeout:       rest.DefaultTimeout,
+		activationURL: DefaultActivationURL,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// WithPublicKey sets the public key used to verify the license: an URL, a path or the PEM content itself
+func WithPublicKey(publicKey string) Option {
+	return func(o *options) error {
+		if publicKey == "" {
+			return newError(ErrInvalidOption, nil, "Empty public key")
+		}
+		o.keySource = GuessSource(publicKey)
+		return nil
+	}
+}
+
+// WithKeySource sets the explicit source of the public key used to verify the license
+func WithKeySource(source KeySource) Option {
+	return func(o *options) error {
+		if source == nil {
+			return newError(ErrInvalidOption, nil, "Nil key source")
+		}
+		o.keySource = source
+		return nil
+	}
+}
+
+// WithKeyring sets the keys trusted to sign licenses, selected by "Key ID" (it replaces WithPublicKey/WithKeySource)
+func WithKeyring(keyring *Keyring) Option {
+	return func(o *options) error {
+		if keyring == nil || len(keyring.keys) == 0 {
+			return newError(ErrInvalidOption, nil, "Empty keyring")
+		}
+		o.keyring = keyring
+		return nil
+	}
+}
+
+// WithKeyCache caches on disk (DefaultKeyCacheDir when dir is empty) the public key fetched from an URL
+// A cached key is used as is during ttl, then revalidated (If-None-Match) and still used when the URL is unreachable
+func WithKeyCache(dir string, ttl time.Duration) Option {
+	return func(o *options) error {
+		if ttl <= 0 {
+			return newError(ErrInvalidOption, nil, "Key cache TTL must be positive, got %s", ttl)
+		}
+		if dir == "" {
+			defaultDir, err := DefaultKeyCacheDir()
+			if err != nil {
+				return newError(ErrInvalidOption, err, "Unable to find key cache directory")
+			}
+			dir = defaultDir
+		}
+		o.keyCache = &keyCache{dir: dir, ttl: ttl}
+		return nil
+	}
+}
+
+// WithKeyPin trusts the public key only if its SHA-256 fingerprint (see Fingerprint) is one of the given ones
+// It's also the only way to trust a key which changed since it was cached
+func WithKeyPin(fingerprints ...string) Option {
+	return func(o *options) error {
+		for _, fingerprint := range fingerprints {
+			normalized := normalizeFingerprint(fingerprint)
+			if _, err := hex.DecodeString(normalized); err != nil || len(normalized) != 64 {
+				return newError(ErrInvalidOption, nil, "Invalid SHA-256 fingerprint %q", fingerprint)
+			}
+			o.keyPins = append(o.keyPins, normalized)
+		}
+		return nil
+	}
+}
+
+// WithJWKS sets the keys trusted to sign licenses from a JSON Web Key Set (Eg: FromURL, FromFile), selected by "Key ID"
+// (it replaces WithPublicKey/WithKeySource)
+func WithJWKS(source KeySource) Option {
+	return func(o *options) error {
+		if source == nil {
+			return newError(ErrInvalidOption, nil, "Nil JWKS source")
+		}
+		o.jwksSource = source
+		return nil
+	}
+}
+
+// WithRevocationSource checks licenses against the signed revocation list read from the source (Eg: FromURL, FromFile)
+func WithRevocationSource(source KeySource) Option {
+	return func(o *options) error {
+		if source == nil {
+			return newError(ErrInvalidOption, nil, "Nil revocation list source")
+		}
+		o.revocationSource = source
+		return nil
+	}
+}
+
+// WithRevocationList checks licenses against an already loaded revocation list (see LoadRevocationList)
+// It replaces WithRevocationSource
+func WithRevocationList(list *RevocationList) Option {
+	return func(o *options) error {
+		if list == nil {
+			return newError(ErrInvalidOption, nil, "Nil revocation list")
+		}
+		o.revocationList = list
+		return nil
+	}
+}
+
+// WithRevocationCache caches on disk (DefaultRevocationCacheDir when dir is empty) the revocation list fetched from an URL
+// A cached list is used as is during ttl, then revalidated (If-None-Match) and still used when the URL is unreachable,
+// until it expires (see RevocationList.ExpiresOn) or for the grace of WithRevocationOfflineGrace
+func WithRevocationCache(dir string, ttl time.Duration) Option {
+	return func(o *options) error {
+		if ttl <= 0 {
+			return newError(ErrInvalidOption, nil, "Revocation cache TTL must be positive, got %s", ttl)
+		}
+		if dir == "" {
+			defaultDir, err := DefaultRevocationCacheDir()
+			if err != nil {
+				return newError(ErrInvalidOption, err, "Unable to find revocation cache directory")
+			}
+			dir = defaultDir
+		}
+		o.revocationCache = &revocationCache{dir: dir, ttl: ttl}
+		return nil
+	}
+}
+
+// WithRevocationOfflineGrace limits how long after its last fetch the cached revocation list is still used when
+// its URL is unreachable (see WithRevocationCache); without it, the cached list is used until it expires
+func WithRevocationOfflineGrace(grace time.Duration) Option {
+	return func(o *options) error {
+		if grace <= 0 {
+			return newError(ErrInvalidOption, nil, "Revocation offline grace must be positive, got %s", grace)
+		}
+		o.revocationGrace = grace
+		return nil
+	}
+}
+
+// WithOnlineCheck queries the licensor API (DefaultStatusURL when statusURL is empty) for the live status of the license,
+// which must be active for Validate to pass; "{serial}" is replaced by the license serial (Eg: "https://licensor.test/licenses/{serial}")
+func WithOnlineCheck(statusURL string) Option {
+	return func(o *options) error {
+		if statusURL == "" {
+			statusURL = DefaultStatusURL
+		}
+		if !strings.Contains(statusURL, "{serial}") || !isURL(strings.ReplaceAll(statusURL, "{serial}", "serial")) {
+			return newError(ErrInvalidOption, nil, "Invalid status URL %q (expected an URL with {serial})", statusURL)
+		}
+		o.statusURL = statusURL
+		return nil
+	}
+}
+
+// WithOfflineGrace keeps the last online check on disk (DefaultStatusCacheDir when dir is empty) and uses it
+// when the licensor API is unreachable, as long as it's not older than grace (see WithOnlineCheck)
+// The cache is not signed: anyone able to write into dir can fake an active status while the API is unreachable
+func WithOfflineGrace(dir string, grace time.Duration) Option {
+	return func(o *options) error {
+		if grace <= 0 {
+			return newError(ErrInvalidOption, nil, "Offline grace must be positive, got %s", grace)
+		}
+		if dir == "" {
+			defaultDir, err := DefaultStatusCacheDir()
+			if err != nil {
+				return newError(ErrInvalidOption, err, "Unable to find status cache directory")
+			}
+			dir = defaultDir
+		}
+		o.statusCache = &statusCache{dir: dir, grace: grace}
+		return nil
+	}
+}
+
+// WithFingerprint sets how the fingerprint of the machine is computed to check node-locked licenses (see MetaFingerprint);
+// it must match the way the fingerprints written into the licenses were computed (default fingerprint options otherwise)
+func WithFingerprint(opts ...fingerprint.Option) Option {
+	return func(o *options) error {
+		o.fingerprintOptions = opts
+		return nil
+	}
+}
+
+// WithHostFingerprint sets the fingerprint of the machine instead of computing it (Eg: computed once by the application)
+func WithHostFingerprint(id string) Option {
+	return func(o *options) error {
+		if strings.TrimSpace(id) == "" {
+			return newError(ErrInvalidOption, nil, "Empty host fingerprint")
+		}
+		o.hostFingerprint = id
+		return nil
+	}
+}
+
+// WithActivationURL sets the licensor API endpoint of the activations (DefaultActivationURL otherwise, see Activate)
+func WithActivationURL(activationURL string) Option {
+	return func(o *options) error {
+		if !isURL(activationURL) {
+			return newError(ErrInvalidOption, nil, "Invalid activation URL %q", activationURL)
+		}
+		o.activationURL = activationURL
+		return nil
+	}
+}
+
+// WithActivationStore sets the directory of the activation tokens (DefaultActivationDir otherwise)
+func WithActivationStore(dir string) Option {
+	return func(o *options) error {
+		if dir == "" {
+			return newError(ErrInvalidOption, nil, "Empty activation directory")
+		}
+		o.activationStore = &activationStore{dir: dir}
+		return nil
+	}
+}
+
+// The store of the activation tokens, into DefaultActivationDir unless set by WithActivationStore
+func (o *options) activations() (*activationStore, error) {
+	if o.activationStore != nil {
+		return o.activationStore, nil
+	}
+	dir, err := DefaultActivationDir()
+	if err != nil {
+		return nil, newError(ErrInvalidOption, err, "Unable to find activation directory")
+	}
+	return &activationStore{dir: dir}, nil
+}
+
+// WithToken sets the authentication token sent (as bearer) when license or key are fetched from BuyMint API
+func WithToken(token string) Option {
+	return func(o *options) error {
+		o.token = token
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to fetch license or key from an URL
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) error {
+		if client == nil {
+			return newError(ErrInvalidOption, nil, "Nil HTTP client")
+		}
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify accepts self-signed certificates when fetching license or key from an URL
+// It has no effect when a custom HTTP client is set with WithHTTPClient
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(o *options) error {
+		o.insecureSkipVerify = skip
+		return nil
+	}
+}
+
+// WithTimeout sets the time limit of every fetch (dial, TLS handshake and body read included), 0 restoring the default one
+// Use NewWithContext to bound the whole loading instead (it's the only bound applied to a WithHTTPClient client)
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout < 0 {
+			return newError(ErrInvalidOption, nil, "Timeout must not be negative, got %s", timeout)
+		}
+		if timeout == 0 {
+			timeout = rest.DefaultTimeout
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithAllowedAlgorithms restricts the signature algorithms accepted by Validate (every supported one otherwise)
+// Eg: WithAllowedAlgorithms(PS256, PS512, RS256) accepts RSA-PSS and legacy PKCS#1 v1.5 licenses only
+func WithAllowedAlgorithms(algorithms ...Algorithm) Option {
+	return func(o *options) error {
+		if len(algorithms) == 0 {
+			return newError(ErrInvalidOption, nil, "No allowed algorithm")
+		}
+		for _, algorithm := range algorithms {
+			if _, err := parseAlgorithm(string(algorithm)); err != nil {
+				return newError(ErrInvalidOption, err, "Invalid allowed algorithm")
+			}
+		}
+		o.allowedAlgorithms = algorithms
+		return nil
+	}
+}
+
+// WithClock sets the clock used to check the license validity window (useful for tests)
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) error {
+		if clock == nil {
+			return newError(ErrInvalidOption, nil, "Nil clock")
+		}
+		o.clock = clock
+		return nil
+	}
+}
+
+// WithClockSkew sets the tolerance applied when checking "Signed on" and "Expires on" (DefaultClockSkew otherwise)
+func WithClockSkew(clockSkew time.Duration) Option {
+	return func(o *options) error {
+		if clockSkew < 0 {
+			return newError(ErrInvalidOption, nil, "Negative clock skew %s", clockSkew)
+		}
+		o.clockSkew = clockSkew
+		return nil
+	}
+}
+
+// WithGracePeriod sets how long an expired license is still accepted (with a warning) after "Expires on"
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(o *options) error {
+		if gracePeriod < 0 {
+			return newError(ErrInvalidOption, nil, "Negative grace period %s", gracePeriod)
+		}
+		o.gracePeriod = gracePeriod
+		return nil
+	}
+}
+
+// WithWarningPeriod sets how long before "Expires on" the license is reported as expiring soon
+func WithWarningPeriod(warningPeriod time.Duration) Option {
+	return func(o *options) error {
+		if warningPeriod < 0 {
+			return newError(ErrInvalidOption, nil, "Negative warning period %s", warningPeriod)
+		}
+		o.warningPeriod = warningPeriod
+		return nil
+	}
+}
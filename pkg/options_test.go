@@ -0,0 +1,90 @@
+package license
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/rest"
+	"github.com/pkg/errors"
+)
+
+func TestRemoteLicenseOptions(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	authorizations := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/license", func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		http.ServeFile(w, r, "../test/assets/license.txt")
+	})
+	mux.HandleFunc("/key", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../test/assets/public.key")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	// Fetching with a token through a custom HTTP client
+	license, err := New(server.URL+"/license", WithPublicKey(server.URL+"/key"), WithToken("secret"), WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if license.Serial != "foo-test-alpha" {
+		t.Errorf("Unexpected serial %q", license.Serial)
+	}
+	// Fetching without a token must not fail
+	if _, err := New(server.URL+"/license", WithPublicKey(server.URL+"/key")); err != nil {
+		t.Fatal(err)
+	}
+	if len(authorizations) != 2 || authorizations[0] != "Bearer secret" || authorizations[1] != "" {
+		t.Errorf("Unexpected authorization headers %q", authorizations)
+	}
+	// Invalid options fail at construction
+	invalid := map[string]Option{
+		"empty public key": WithPublicKey(""),
+		"nil HTTP client":  WithHTTPClient(nil),
+		"nil clock":        WithClock(nil),
+		"negative grace":   WithGracePeriod(-1),
+		"negative warning": WithWarningPeriod(-1),
+	}
+	for name, opt := range invalid {
+		if _, err := New(server.URL+"/license", opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: expected ErrInvalidOption, got %v", name, err)
+		}
+	}
+}
+
+func TestRemoteLicenseTimeout(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	// Deadline carried by the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := NewWithContext(ctx, server.URL+"/license")
+	if !errors.Is(err, ErrFetch) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrFetch caused by the context deadline, got %v", err)
+	}
+	// Time limit set by option
+	start := time.Now()
+	_, err = New(server.URL+"/license", WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrFetch) || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected ErrFetch after the timeout, got %v after %s", err, time.Since(start))
+	}
+	// 0 restores the default timeout, negative ones are rejected
+	var o options
+	if err := WithTimeout(0)(&o); err != nil || o.timeout != rest.DefaultTimeout {
+		t.Errorf("Expected the default timeout, got %s (%v)", o.timeout, err)
+	}
+	if err := WithTimeout(-time.Second)(&o); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a negative timeout, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package license
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"hash"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Private keys are encrypted as PKCS #8 (RFC 5958) with PBES2 (RFC 8018): PBKDF2 with HMAC-SHA256 and AES-256-CBC,
+// which is the "ENCRYPTED PRIVATE KEY" of "openssl pkcs8 -topk8 -v2 aes-256-cbc"
+const encryptedPrivateKeyType = "ENCRYPTED PRIVATE KEY"
+
+// PBKDF2 iterations of the encrypted private keys (OWASP recommendation for HMAC-SHA256)
+const pbkdf2Iterations = 600000
+
+// Bounds of the PBKDF2 iterations accepted when decrypting, the upper one avoids hanging on a crafted key
+const (
+	minPBKDF2Iterations = 1000
+	maxPBKDF2Iterations = 10000000
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// Encrypting a PKCS #8 private key with the passphrase
+func encryptPKCS8(der []byte, passphrase []byte) (*pem.Block, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, pbkdf2Iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	// PKCS #7 padding
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	encrypted := append(append([]byte(nil), der...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	content, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: encryptedPrivateKeyType, Bytes: content}, nil
+}
+
+// Decrypting an encrypted PKCS #8 private key (PBES2 with PBKDF2 and AES-CBC) with the passphrase
+func decryptPKCS8(content []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(content, &info); err != nil || len(rest) > 0 {
+		return nil, errors.New("Malformed encrypted private key")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.Errorf("Unsupported private key encryption %s (expected PBES2)", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, errors.Wrap(err, "Malformed PBES2 parameters")
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errors.Errorf("Unsupported key derivation %s (expected PBKDF2)", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, errors.Wrap(err, "Malformed PBKDF2 parameters")
+	}
+	if kdfParams.IterationCount < minPBKDF2Iterations || kdfParams.IterationCount > maxPBKDF2Iterations {
+		return nil, errors.Errorf("Unsupported PBKDF2 iteration count %d", kdfParams.IterationCount)
+	}
+	newHash, err := pbkdf2Hash(kdfParams.PRF)
+	if err != nil {
+		return nil, err
+	}
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		return nil, errors.Errorf("Unsupported private key cipher %s (expected AES-CBC)", params.EncryptionScheme.Algorithm)
+	}
+	if kdfParams.KeyLength != 0 && kdfParams.KeyLength != keyLength {
+		return nil, errors.Errorf("Invalid PBKDF2 key length %d", kdfParams.KeyLength)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("Malformed AES-CBC parameters")
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, errors.New("Malformed encrypted private key")
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLength, newHash))
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+	// Checking the PKCS #7 padding, a wrong passphrase nearly always breaks it
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("Wrong passphrase")
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+// Selecting the hash of the PBKDF2 pseudorandom function (HMAC-SHA1 when absent, as defined by RFC 8018)
+func pbkdf2Hash(prf pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	case prf.Algorithm == nil || prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	}
+	return nil, errors.Errorf("Unsupported PBKDF2 function %s", prf.Algorithm)
+}
@@ -0,0 +1,118 @@
+package license
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Policy declares the requirements of a license, usually loaded from a YAML/JSON policy file
+// Every empty field is ignored
+type Policy struct {
+	// Meta are the required metadata as requirement expressions (Eg: "agency=A144109", "seats>=10"), see ParseRequirement
+	Meta []string `json:"meta" yaml:"meta" mapstructure:"meta"`
+	// MetaKeys are requirements on metadata by literal key, never read as a dotted path (Eg: the JSON objects of
+	// --meta, whose keys may hold spaces, dots or operators); policy files only declare Meta
+	MetaKeys map[string]Requirement `json:"-" yaml:"-" mapstructure:"-"`
+	// MinRemaining is the minimum time left before the license expires (perpetual licenses always satisfy it)
+	MinRemaining time.Duration `json:"min_remaining" yaml:"min_remaining" mapstructure:"min_remaining"`
+	// Issuers are the allowed issuers, matching the name or the email of "Issued by" (case insensitive)
+	Issuers []string `json:"issuers" yaml:"issuers" mapstructure:"issuers"`
+	// KeyIDs are the allowed key IDs ("Key ID" header)
+	KeyIDs []string `json:"key_ids" yaml:"key_ids" mapstructure:"key_ids"`
+	// Algorithms are the accepted signature algorithms
+	Algorithms []Algorithm `json:"algorithms" yaml:"algorithms" mapstructure:"algorithms"`
+}
+
+// Names of the policy checks reported into a ValidationResult
+const (
+	CheckPolicyRemaining = CheckPolicyPrefix + "min_remaining"
+	CheckPolicyIssuer    = CheckPolicyPrefix + "issuer"
+	CheckPolicyKeyID     = CheckPolicyPrefix + "key_id"
+	CheckPolicyAlgorithm = CheckPolicyPrefix + "algorithm"
+)
+
+// Requirements parses the required metadata of the policy, ready to be given to Validate
+// (the requirements on the same path are combined with All, Eg: "seats>=10" and "seats<=20")
+func (p Policy) Requirements() (map[string]interface{}, error) {
+	meta := map[string]interface{}{}
+	for _, expression := range p.Meta {
+		path, requirement, err := ParseRequirement(expression)
+		if err != nil {
+			return nil, err
+		}
+		if previous, found := meta[path]; found {
+			requirement = All(previous.(Requirement), requirement)
+		}
+		meta[path] = requirement
+	}
+	return meta, nil
+}
+
+// ValidateWithPolicy validates the license (see Validate) with the metadata required by the policy (Meta and MetaKeys),
+// then checks its remaining validity, issuer, key ID and algorithm against the policy (ErrPolicyViolation)
+func (t *License) ValidateWithPolicy(policy Policy) (*ValidationResult, error) {
+	meta, err := policy.Requirements()
+	if err != nil {
+		return nil, err
+	}
+	result, _ := t.Validate(meta)
+	keys := make([]string, 0, len(policy.MetaKeys))
+	for key := range policy.MetaKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		actual, found := t.Meta[key]
+		result.add(newMetaCheck(key, policy.MetaKeys[key], actual, found))
+	}
+	if policy.MinRemaining > 0 {
+		result.add(newCheck(CheckPolicyRemaining, t.checkMinRemaining(result.Validity, policy.MinRemaining)))
+	}
+	if len(policy.Issuers) > 0 {
+		result.add(newCheck(CheckPolicyIssuer, t.checkIssuer(policy.Issuers)))
+	}
+	if len(policy.KeyIDs) > 0 {
+		result.add(newCheck(CheckPolicyKeyID, checkAllowed("Key ID", t.KeyID, policy.KeyIDs)))
+	}
+	if len(policy.Algorithms) > 0 {
+		allowed := make([]string, 0, len(policy.Algorithms))
+		for _, algorithm := range policy.Algorithms {
+			allowed = append(allowed, string(algorithm))
+		}
+		result.add(newCheck(CheckPolicyAlgorithm, checkAllowed("Algorithm", string(t.Algorithm), allowed)))
+	}
+	return result, result.Err()
+}
+
+// Checking the license doesn't expire within the minimum remaining validity
+func (t *License) checkMinRemaining(validity Validity, minRemaining time.Duration) error {
+	if t.ExpiresOn.IsZero() || validity.Status == StatusExpired || validity.Status == StatusNotYetValid {
+		// Perpetual licenses always satisfy it, expired ones are already reported by the expiry check
+		return nil
+	}
+	if validity.Remaining < minRemaining {
+		return newError(ErrPolicyViolation, nil, "License expires on %s, less than %s from now", validity.ExpiresOn.Format(time.RFC3339), minRemaining)
+	}
+	return nil
+}
+
+// Checking the issuer is one of the allowed ones (by name or email)
+func (t *License) checkIssuer(issuers []string) error {
+	for _, issuer := range issuers {
+		if (t.IssuedBy.Name != "" && strings.EqualFold(issuer, t.IssuedBy.Name)) || (t.IssuedBy.Email != "" && strings.EqualFold(issuer, t.IssuedBy.Email)) {
+			return nil
+		}
+	}
+	return newError(ErrPolicyViolation, nil, "Issuer %q is not allowed", t.IssuedBy.String())
+}
+
+// Checking a header value is one of the allowed ones
+func checkAllowed(header string, value string, allowed []string) error {
+	for _, allowedValue := range allowed {
+		if value == allowedValue {
+			return nil
+		}
+	}
+	return newError(ErrPolicyViolation, nil, "%s %q is not allowed (expected one of %s)", header, value, strings.Join(allowed, ", "))
+}
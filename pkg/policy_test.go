@@ -0,0 +1,86 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestValidateWithPolicy(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	content, err := Issue(License{
+		Serial:    "foo-policy",
+		Meta:      map[string]interface{}{"agency": "A144109", "seats": 10, "my key": 1, "customer": map[string]interface{}{"region": "eu-west"}},
+		SignedOn:  signedOn,
+		ExpiresOn: signedOn.AddDate(0, 3, 0),
+		IssuedBy:  Identity{Name: "Foo Test", Type: "user", Email: "foo@test.cloud"},
+		KeyID:     "2022-05",
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := New(content, WithPublicKey(string(publicKey)), WithClock(fixedClock("2022-06-01T00:00:00Z")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := Policy{
+		Meta:         []string{"agency=A144109", "seats>=10"},
+		MinRemaining: 30 * 24 * time.Hour,
+		Issuers:      []string{"FOO@test.cloud"},
+		KeyIDs:       []string{"2022-04", "2022-05"},
+		Algorithms:   []Algorithm{EdDSA, ES256},
+	}
+	if result, err := license.ValidateWithPolicy(policy); err != nil {
+		t.Errorf("Expected the policy to be satisfied, got %v (%+v)", err, result.Failed())
+	}
+	// Every violation is reported
+	violations := map[string]Policy{
+		CheckPolicyRemaining: {MinRemaining: 90 * 24 * time.Hour},
+		CheckPolicyIssuer:    {Issuers: []string{"Bar Test"}},
+		CheckPolicyKeyID:     {KeyIDs: []string{"2022-04"}},
+		CheckPolicyAlgorithm: {Algorithms: []Algorithm{RS256}},
+	}
+	for name, policy := range violations {
+		result, err := license.ValidateWithPolicy(policy)
+		if !errors.Is(err, ErrPolicyViolation) {
+			t.Errorf("%s: expected ErrPolicyViolation, got %v", name, err)
+			continue
+		}
+		if failed := result.Failed(); len(failed) != 1 || failed[0].Name != name {
+			t.Errorf("%s: unexpected failed checks %+v", name, failed)
+		}
+	}
+	if _, err := license.ValidateWithPolicy(Policy{Meta: []string{"seats>=20"}}); !errors.Is(err, ErrMetadataMismatch) {
+		t.Errorf("Expected ErrMetadataMismatch, got %v", err)
+	}
+	// Every requirement on the same path is kept (Eg: a range)
+	if result, err := license.ValidateWithPolicy(Policy{Meta: []string{"seats>=5", "seats<=20"}}); err != nil {
+		t.Errorf("Expected seats into the range, got %v (%+v)", err, result.Failed())
+	}
+	for _, meta := range [][]string{{"seats>=20", "seats<=100"}, {"seats>=1", "seats<=5"}} {
+		if _, err := license.ValidateWithPolicy(Policy{Meta: meta}); !errors.Is(err, ErrMetadataMismatch) {
+			t.Errorf("Expected ErrMetadataMismatch for seats out of %v, got %v", meta, err)
+		}
+	}
+	// MetaKeys are literal keys, never dotted paths
+	if result, err := license.ValidateWithPolicy(Policy{MetaKeys: map[string]Requirement{"my key": Equal(1), "agency": Equal("A144109")}}); err != nil {
+		t.Errorf("Expected the literal keys to match, got %v (%+v)", err, result.Failed())
+	}
+	if _, err := license.ValidateWithPolicy(Policy{MetaKeys: map[string]Requirement{"customer.region": Equal("eu-west")}}); !errors.Is(err, ErrMetadataMismatch) {
+		t.Errorf("Expected ErrMetadataMismatch for a dotted literal key, got %v", err)
+	}
+	if _, err := license.ValidateWithPolicy(Policy{Meta: []string{"seats"}}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for an invalid requirement, got %v", err)
+	}
+}
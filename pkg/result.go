@@ -0,0 +1,94 @@
+package license
+
+// Names of the checks reported into a ValidationResult
+const (
+	// CheckSignature verifies the license message against its signature
+	CheckSignature = "signature"
+	// CheckRevocation verifies the license is not revoked (only when a revocation list is configured)
+	CheckRevocation = "revocation"
+	// CheckOnline verifies the licensor API reports the license as active (only with WithOnlineCheck)
+	CheckOnline = "online"
+	// CheckFingerprint verifies the license is bound to the current machine (only for node-locked licenses)
+	CheckFingerprint = "fingerprint"
+	// CheckActivation verifies the license is activated on the current machine (only for seat-limited licenses)
+	CheckActivation = "activation"
+	// CheckExpiry verifies the license is inside its validity window
+	CheckExpiry = "expiry"
+	// CheckMetaPrefix prefixes the name of every metadata check (Eg: "meta.agency")
+	CheckMetaPrefix = "meta."
+	// CheckPolicyPrefix prefixes the name of every policy check (Eg: "policy.issuer")
+	CheckPolicyPrefix = "policy."
+)
+
+// Check is the outcome of a single verification run by Validate
+type Check struct {
+	Name     string      `json:"name"`
+	Passed   bool        `json:"passed"`
+	Reason   string      `json:"reason,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	err      error
+}
+
+// Building a check from the error returned by a verification
+func newCheck(name string, err error) Check {
+	check := Check{Name: name, Passed: true}
+	check.fail(err)
+	return check
+}
+
+// Marking the check as failed (nothing happens with a nil error)
+func (c *Check) fail(err error) {
+	if err == nil {
+		return
+	}
+	c.Passed = false
+	c.Reason = err.Error()
+	c.err = err
+}
+
+// Err is the error which made the check fail (nil if passed)
+func (c Check) Err() error {
+	return c.err
+}
+
+// ValidationResult lists every check run by Validate
+type ValidationResult struct {
+	Valid    bool     `json:"valid"`
+	Checks   []Check  `json:"checks"`
+	Validity Validity `json:"validity"`
+	// Online is the outcome of the online verification (nil when not enabled or when the licensor API failed)
+	Online *OnlineCheck `json:"online,omitempty"`
+	// Activation is the stored activation of the seat-limited license (nil when not seat-limited or not activated on this machine)
+	Activation *Activation `json:"activation,omitempty"`
+}
+
+// Adding a check to the result, the result is valid as long as every check passes
+func (r *ValidationResult) add(check Check) {
+	if len(r.Checks) == 0 {
+		r.Valid = true
+	}
+	r.Checks = append(r.Checks, check)
+	r.Valid = r.Valid && check.Passed
+}
+
+// Failed lists the checks which didn't pass
+func (r *ValidationResult) Failed() []Check {
+	var failed []Check
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Err is the error of the first failed check (nil if the license is valid)
+func (r *ValidationResult) Err() error {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return check.err
+		}
+	}
+	return nil
+}
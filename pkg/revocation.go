@@ -0,0 +1,203 @@
+package license
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Revocation revokes the licenses with the given serial or transaction ID (Eg: leaked licenses)
+type Revocation struct {
+	Serial        string    `json:"serial,omitempty"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	RevokedOn     time.Time `json:"revoked_on,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// DefaultRevocationListValidity is how long a revocation list is trusted when IssueRevocationList sets its expiry
+const DefaultRevocationListValidity = 7 * 24 * time.Hour
+
+// RevocationList lists the revoked licenses, signed by the licensor (see IssueRevocationList)
+type RevocationList struct {
+	// IssuedOn dates the list: a list older than the cached one is never trusted
+	IssuedOn time.Time `json:"issued_on"`
+	// ExpiresOn is the time the list is no longer trusted, so that replaying an old list can't un-revoke licenses:
+	// the licensor publishes a new list before it (Eg: its next update)
+	ExpiresOn   time.Time    `json:"expires_on"`
+	Revocations []Revocation `json:"revocations"`
+}
+
+// revocationEnvelope is the signed form of a revocation list: the list is kept as the JSON text which was signed
+type revocationEnvelope struct {
+	List      string    `json:"list"`
+	Algorithm Algorithm `json:"algorithm"`
+	KeyID     string    `json:"key_id,omitempty"`
+	Signature string    `json:"signature"`
+}
+
+// RevokedError is returned when the license is revoked by the revocation list
+type RevokedError struct {
+	Serial     string
+	Revocation Revocation
+}
+
+func (e *RevokedError) Error() string {
+	message := fmt.Sprintf("License %q is revoked", e.Serial)
+	if !e.Revocation.RevokedOn.IsZero() {
+		message += " since " + e.Revocation.RevokedOn.Format(time.RFC3339)
+	}
+	if e.Revocation.Reason != "" {
+		message += ": " + e.Revocation.Reason
+	}
+	return message
+}
+
+// Is makes errors.Is(err, ErrRevoked) work
+func (e *RevokedError) Is(target error) bool {
+	return target == ErrRevoked
+}
+
+// Find looks up the revocation of a license by serial or transaction ID
+func (l *RevocationList) Find(serial string, transactionID string) (Revocation, bool) {
+	for _, revocation := range l.Revocations {
+		if (revocation.Serial != "" && revocation.Serial == serial) || (revocation.TransactionID != "" && revocation.TransactionID == transactionID) {
+			return revocation, true
+		}
+	}
+	return Revocation{}, false
+}
+
+// Checking the list is not expired (with the clock skew tolerance)
+func (l *RevocationList) checkExpiry(now time.Time, clockSkew time.Duration) error {
+	if now.After(l.ExpiresOn.Add(clockSkew)) {
+		return newError(ErrRevocationListExpired, nil, "Revocation list issued on %s expired on %s", l.IssuedOn.Format(time.RFC3339), l.ExpiresOn.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Checking the list is still trusted and the license is not revoked
+func (l *RevocationList) check(t *License) error {
+	if err := l.checkExpiry(t.now(), t.clockSkew); err != nil {
+		return err
+	}
+	if revocation, found := l.Find(t.Serial, t.TransactionID); found {
+		return &RevokedError{Serial: t.Serial, Revocation: revocation}
+	}
+	return nil
+}
+
+// IssueRevocationList signs the revocation list with the licensor private key (with the algorithm of the key,
+// RS256 for RSA keys); keyID selects the key into the keyring of the readers (Eg: KeyID of the key, or empty)
+// IssuedOn defaults to now and ExpiresOn to DefaultRevocationListValidity later
+func IssueRevocationList(list RevocationList, key crypto.Signer, keyID string) ([]byte, error) {
+	if key == nil {
+		return nil, newError(ErrInvalidKey, nil, "Missing private key")
+	}
+	if list.IssuedOn.IsZero() {
+		list.IssuedOn = time.Now().UTC()
+	}
+	if list.ExpiresOn.IsZero() {
+		list.ExpiresOn = list.IssuedOn.Add(DefaultRevocationListValidity)
+	}
+	if !list.ExpiresOn.After(list.IssuedOn) {
+		return nil, newError(ErrInvalidOption, nil, "Revocation list expires on %s, before being issued", list.ExpiresOn.Format(time.RFC3339))
+	}
+	content, err := json.Marshal(list)
+	if err != nil {
+		return nil, newError(ErrInvalidOption, err, "Unable to encode revocation list")
+	}
+	algorithm, signature, err := signPayload(key, content)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(revocationEnvelope{
+		List:      string(content),
+		Algorithm: algorithm,
+		KeyID:     keyID,
+		Signature: signature,
+	}, "", "  ")
+}
+
+// ParseRevocationList verifies the signed revocation list with the trusted keys and reads it
+// Its expiry is checked by the validations using it (see WithRevocationList)
+func ParseRevocationList(content []byte, keyring *Keyring) (*RevocationList, error) {
+	return parseRevocationList(content, keyring, &options{clock: time.Now})
+}
+
+// Verifying the signature of the revocation list with the key selected by its key ID, then reading it
+func parseRevocationList(content []byte, keyring *Keyring, o *options) (*RevocationList, error) {
+	var envelope revocationEnvelope
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return nil, newError(ErrInvalidFormat, err, "Unable to parse revocation list")
+	}
+	if err := verifyPayload([]byte(envelope.List), envelope.Algorithm, envelope.KeyID, envelope.Signature, keyring, o); err != nil {
+		return nil, errors.Wrap(err, "Unable to verify revocation list")
+	}
+	var list RevocationList
+	if err := json.Unmarshal([]byte(envelope.List), &list); err != nil {
+		return nil, newError(ErrInvalidFormat, err, "Unable to parse revocation list")
+	}
+	if list.ExpiresOn.IsZero() {
+		return nil, newError(ErrInvalidFormat, nil, "Revocation list has no expiry date")
+	}
+	return &list, nil
+}
+
+// LoadRevocationList resolves the revocation list of the options (WithRevocationSource, through the cache
+// of WithRevocationCache), verified with the trusted keys; use it with WithRevocationList to share it between many licenses
+func LoadRevocationList(ctx context.Context, opts ...Option) (*RevocationList, error) {
+	return loadRevocations(ctx, opts, false)
+}
+
+// SyncRevocationList fetches the revocation list right away, whatever the TTL of the cache, and caches it
+// so that later validations use it even offline
+func SyncRevocationList(ctx context.Context, opts ...Option) (*RevocationList, error) {
+	return loadRevocations(ctx, opts, true)
+}
+
+func loadRevocations(ctx context.Context, opts []Option, force bool) (*RevocationList, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.revocationList == nil && o.revocationSource == nil {
+		return nil, newError(ErrInvalidOption, nil, "No revocation list source")
+	}
+	keyring, err := loadKeyring(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return loadRevocationList(ctx, o, keyring, force)
+}
+
+// Using the given revocation list or reading it from its source (through the cache when enabled); nil when not configured
+func loadRevocationList(ctx context.Context, o *options, keyring *Keyring, force bool) (*RevocationList, error) {
+	if o.revocationList != nil {
+		return o.revocationList, nil
+	}
+	if o.revocationSource == nil {
+		return nil, nil
+	}
+	urlSource, isURL := o.revocationSource.(*URLSource)
+	if o.revocationCache != nil && isURL {
+		return o.revocationCache.load(ctx, urlSource.withOptions(o), keyring, o, force)
+	}
+	content, err := readKey(ctx, o.revocationSource, o)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read revocation list")
+	}
+	logger.Debug("Verifying revocation list:\n\n%s", content)
+	list, err := parseRevocationList(content, keyring, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := list.checkExpiry(o.clock(), o.clockSkew); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,313 @@
+package license
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestRevocationList(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	issue := func(serial, transactionID string) string {
+		content, err := Issue(License{Serial: serial, TransactionID: transactionID, SignedOn: signedOn, ExpiresOn: signedOn.AddDate(1, 0, 0)}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	revoked := RevocationList{
+		IssuedOn:  signedOn.AddDate(0, 1, 0),
+		ExpiresOn: signedOn.AddDate(0, 6, 0),
+		Revocations: []Revocation{
+			{Serial: "foo-leaked", RevokedOn: signedOn.AddDate(0, 1, 0), Reason: "leaked"},
+			{TransactionID: "tx-refunded"},
+		},
+	}
+	list, err := IssueRevocationList(revoked, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Revocation list server supporting ETag revalidation
+	var mutex sync.Mutex
+	served, requests, online := list, 0, true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sum := sha256.Sum256(served)
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(served)
+	}))
+	defer server.Close()
+	serve := func(content []byte, up bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		served, online = content, up
+	}
+	dir := t.TempDir()
+	now := signedOn.AddDate(0, 2, 0)
+	opts := func(opts ...Option) []Option {
+		return append([]Option{
+			WithPublicKey(string(publicKey)),
+			WithRevocationSource(FromURL(server.URL + "/revocations")),
+			WithRevocationCache(dir, time.Hour),
+			WithClock(func() time.Time { return now }),
+		}, opts...)
+	}
+	validate := func(content string) (*ValidationResult, error) {
+		license, err := New(content, opts()...)
+		if err != nil {
+			return nil, err
+		}
+		return license.Validate(nil)
+	}
+	// Revoked licenses, by serial or by transaction ID, are reported as such
+	for _, content := range []string{issue("foo-leaked", ""), issue("foo-refunded", "tx-refunded")} {
+		result, err := validate(content)
+		var revokedErr *RevokedError
+		if !errors.Is(err, ErrRevoked) || !errors.As(err, &revokedErr) || errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("Expected a revoked license, got %v", err)
+		}
+		if failed := result.Failed(); len(failed) != 1 || failed[0].Name != CheckRevocation {
+			t.Errorf("Expected the revocation check to fail, got %+v", failed)
+		}
+	}
+	if _, err := validate(issue("foo-valid", "tx-valid")); err != nil {
+		t.Errorf("Expected a valid license, got %v", err)
+	}
+	// The list was fetched once and cached
+	mutex.Lock()
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+	mutex.Unlock()
+	// Once stale, an unreachable list is still used from the cache
+	now = now.Add(2 * time.Hour)
+	serve(list, false)
+	if _, err := validate(issue("foo-leaked", "")); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected a revoked license from the stale cache, got %v", err)
+	}
+	// Syncing fails offline, whatever the cache
+	if _, err := SyncRevocationList(context.Background(), opts()...); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected a fetch error, got %v", err)
+	}
+	serve(list, true)
+	// An older list is never trusted over the cached one
+	older, err := IssueRevocationList(RevocationList{IssuedOn: signedOn}, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(older, true)
+	if synced, err := SyncRevocationList(context.Background(), opts()...); err != nil || len(synced.Revocations) != 2 {
+		t.Errorf("Expected the cached list, got %+v (%v)", synced, err)
+	}
+	// A newer list replaces the cached one
+	newer, err := IssueRevocationList(RevocationList{IssuedOn: now}, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve(newer, true)
+	if synced, err := SyncRevocationList(context.Background(), opts()...); err != nil || len(synced.Revocations) != 0 {
+		t.Errorf("Expected the newer list, got %+v (%v)", synced, err)
+	}
+	if _, err := validate(issue("foo-leaked", "")); err != nil {
+		t.Errorf("Expected a valid license once unrevoked, got %v", err)
+	}
+	// A list signed by another key is rejected, even read from a file
+	otherKey, err := GenerateKey(ES256, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := IssueRevocationList(RevocationList{}, otherKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	if err := os.WriteFile(path, forged, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(issue("foo-valid", ""), WithPublicKey(string(publicKey)), WithRevocationSource(FromFile(path))); !errors.Is(err, ErrInvalidKey) && !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a forged list to be rejected, got %v", err)
+	}
+	// A loaded list is shared between licenses
+	keyring, err := LoadKeyring(context.Background(), WithPublicKey(string(publicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseRevocationList(list, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := New(issue("foo-leaked", ""), WithKeyring(keyring), WithRevocationList(parsed), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected a revoked license, got %v", err)
+	}
+	if _, err := LoadRevocationList(context.Background(), WithKeyring(keyring)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option without revocation list, got %v", err)
+	}
+}
+
+func TestRevocationListExpiry(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	key, err := GenerateKey(EdDSA, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := EncodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedOn := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	leaked, err := Issue(License{Serial: "foo-leaked", SignedOn: signedOn, ExpiresOn: signedOn.AddDate(1, 0, 0)}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The list before the revocation of the leaked license, replaced a week later
+	before, err := IssueRevocationList(RevocationList{IssuedOn: signedOn}, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := IssueRevocationList(RevocationList{IssuedOn: signedOn.AddDate(0, 0, 7), Revocations: []Revocation{{Serial: "foo-leaked"}}}, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := signedOn.AddDate(0, 0, 10)
+	clock := WithClock(func() time.Time { return now })
+	// Replaying the expired list from a file doesn't un-revoke the license
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	if err := os.WriteFile(path, before, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(leaked, WithPublicKey(string(publicKey)), WithRevocationSource(FromFile(path)), clock); !errors.Is(err, ErrRevocationListExpired) {
+		t.Errorf("Expected ErrRevocationListExpired for a replayed list, got %v", err)
+	}
+	// A loaded list stops being trusted once expired
+	keyring, err := LoadKeyring(context.Background(), WithPublicKey(string(publicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseRevocationList(before, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, err := New(leaked, WithKeyring(keyring), WithRevocationList(parsed), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := license.Validate(nil); !errors.Is(err, ErrRevocationListExpired) || result.Failed()[0].Name != CheckRevocation {
+		t.Errorf("Expected the revocation check to fail with ErrRevocationListExpired, got %v", err)
+	}
+	// Lists without expiry are malformed, expiring before being issued can't be signed
+	undated, err := json.Marshal(map[string]interface{}{"issued_on": signedOn, "revocations": []Revocation{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	algorithm, signature, err := signPayload(key, undated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := json.Marshal(revocationEnvelope{List: string(undated), Algorithm: algorithm, Signature: signature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRevocationList(envelope, keyring); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat for a list without expiry, got %v", err)
+	}
+	if _, err := IssueRevocationList(RevocationList{IssuedOn: signedOn, ExpiresOn: signedOn.AddDate(0, 0, -1)}, key, ""); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a list expiring before being issued, got %v", err)
+	}
+	// A cached list is used offline within the offline grace and until it expires
+	var mutex sync.Mutex
+	served, online := after, true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(served)
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	validate := func() error {
+		license, err := New(leaked, WithPublicKey(string(publicKey)), WithRevocationSource(FromURL(server.URL+"/revocations")),
+			WithRevocationCache(dir, time.Hour), WithRevocationOfflineGrace(48*time.Hour), WithClock(func() time.Time { return now }))
+		if err != nil {
+			return err
+		}
+		_, err = license.Validate(nil)
+		return err
+	}
+	if err := validate(); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("Expected a revoked license, got %v", err)
+	}
+	mutex.Lock()
+	online = false
+	mutex.Unlock()
+	now = now.Add(24 * time.Hour)
+	if err := validate(); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected a revoked license from the cache within the offline grace, got %v", err)
+	}
+	now = now.Add(48 * time.Hour)
+	if err := validate(); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected ErrFetch once the offline grace is exceeded, got %v", err)
+	}
+	// The unsigned fetch date of the cache can't be moved into the future to extend the grace
+	cachePath := (&revocationCache{dir: dir}).path(server.URL + "/revocations")
+	content, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry revocationCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.FetchedAt = now.AddDate(1, 0, 0)
+	if err := writeCacheFile(dir, cachePath, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(); !errors.Is(err, ErrFetch) {
+		t.Errorf("Expected ErrFetch for a cache fetched in the future, got %v", err)
+	}
+	// Back online, the expired cached list is refreshed; without a new list, validations fail
+	mutex.Lock()
+	online = true
+	mutex.Unlock()
+	now = signedOn.AddDate(0, 1, 0)
+	if err := validate(); !errors.Is(err, ErrRevocationListExpired) {
+		t.Errorf("Expected ErrRevocationListExpired for an outdated list, got %v", err)
+	}
+}
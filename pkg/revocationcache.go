@@ -0,0 +1,145 @@
+package license
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// DefaultRevocationCacheDir is the directory where revocation lists are cached (under the user cache directory)
+func DefaultRevocationCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buymint-cli", "revocations"), nil
+}
+
+// revocationCache stores fetched revocation lists on disk, one file per URL
+type revocationCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// revocationCacheEntry is the content of a cache file; the list is kept signed and verified on every read
+type revocationCacheEntry struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+	List      string    `json:"list"`
+}
+
+// Path of the cache file of an URL
+func (c *revocationCache) path(URL string) string {
+	sum := sha256.Sum256([]byte(URL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Reading the cache entry of an URL (nil if missing or unreadable)
+func (c *revocationCache) read(URL string) *revocationCacheEntry {
+	content, err := os.ReadFile(c.path(URL))
+	if err != nil {
+		return nil
+	}
+	var entry revocationCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.URL != URL {
+		logger.Warn("Ignoring corrupted revocation cache entry for %q", URL)
+		return nil
+	}
+	return &entry
+}
+
+// Writing the cache entry of an URL
+func (c *revocationCache) write(entry *revocationCacheEntry) error {
+	return writeCacheFile(c.dir, c.path(entry.URL), entry)
+}
+
+// Loading the revocation list of an URL: a fresh cached list is used as is (unless forced), a stale one is
+// revalidated (If-None-Match) and used offline when the URL is unreachable, until it expires or the offline grace
+// is exceeded; a list older than the cached one is ignored
+func (c *revocationCache) load(ctx context.Context, source *URLSource, keyring *Keyring, o *options, force bool) (*RevocationList, error) {
+	now := o.clock()
+	entry := c.read(source.URL)
+	if entry != nil && entry.FetchedAt.After(now.Add(o.clockSkew)) {
+		// The cache file is not signed: a fetch date in the future would extend the offline grace forever
+		logger.Warn("Ignoring revocation cache entry of %q fetched in the future (%s)", source.URL, entry.FetchedAt.Format(time.RFC3339))
+		entry = nil
+	}
+	var cached *RevocationList
+	if entry != nil {
+		list, err := parseRevocationList([]byte(entry.List), keyring, o)
+		if err != nil {
+			logger.Warn("Ignoring invalid cached revocation list of %q: %s", source.URL, err)
+			entry = nil
+		}
+		cached = list
+	}
+	// An expired cached list is only kept to refuse older lists
+	var expired error
+	if cached != nil {
+		expired = cached.checkExpiry(now, o.clockSkew)
+	}
+	if cached != nil && expired == nil && !force && now.Sub(entry.FetchedAt) < c.ttl {
+		logger.Debug("Using cached revocation list of %q (fetched at %s)", source.URL, entry.FetchedAt)
+		return cached, nil
+	}
+	etag := ""
+	if entry != nil {
+		etag = entry.ETag
+	}
+	status, content, newETag, err := source.fetchConditional(ctx, etag)
+	if err != nil {
+		switch {
+		case cached == nil || force:
+			return nil, errors.Wrap(err, "Unable to read revocation list")
+		case expired != nil:
+			return nil, errors.Wrapf(expired, "Unable to read revocation list of %q (%s)", source.URL, err)
+		case o.revocationGrace > 0 && now.Sub(entry.FetchedAt) > o.revocationGrace:
+			return nil, newError(ErrFetch, err, "Unable to read revocation list of %q since %s (offline grace of %s exceeded)", source.URL, entry.FetchedAt.Format(time.RFC3339), o.revocationGrace)
+		}
+		logger.Warn("Using stale cached revocation list of %q: %s", source.URL, err)
+		return cached, nil
+	}
+	if status == http.StatusNotModified && cached != nil {
+		if expired != nil {
+			return nil, expired
+		}
+		entry.FetchedAt = now
+		if err := c.write(entry); err != nil {
+			logger.Warn("Unable to update revocation cache: %s", err)
+		}
+		return cached, nil
+	}
+	list, err := parseRevocationList(content, keyring, o)
+	if err != nil {
+		return nil, err
+	}
+	// Replaying an older list would un-revoke licenses
+	if cached != nil && list.IssuedOn.Before(cached.IssuedOn) {
+		logger.Warn("Ignoring revocation list of %q issued on %s, older than the cached one (issued on %s)", source.URL, list.IssuedOn, cached.IssuedOn)
+		if expired != nil {
+			return nil, expired
+		}
+		return cached, nil
+	}
+	if err := list.checkExpiry(now, o.clockSkew); err != nil {
+		return nil, err
+	}
+	if err := c.write(&revocationCacheEntry{
+		URL:       source.URL,
+		ETag:      newETag,
+		FetchedAt: now,
+		List:      string(content),
+	}); err != nil {
+		logger.Warn("Unable to write revocation cache: %s", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,232 @@
+package license
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Cause of the failed verifications of ECDSA and Ed25519 signatures
+var errSignatureMismatch = errors.New("Signature mismatch")
+
+// Algorithm is the signature algorithm of a license, declared by its "Algorithm" header
+type Algorithm string
+
+const (
+	// RS256 is RSA PKCS#1 v1.5 with SHA-256 (assumed for licenses without "Algorithm" header)
+	RS256 Algorithm = "RS256"
+	// PS256 is RSA-PSS with SHA-256
+	PS256 Algorithm = "PS256"
+	// PS512 is RSA-PSS with SHA-512
+	PS512 Algorithm = "PS512"
+	// ES256 is ECDSA on curve P-256 with SHA-256 (ASN.1 DER signature)
+	ES256 Algorithm = "ES256"
+	// ES384 is ECDSA on curve P-384 with SHA-384 (ASN.1 DER signature)
+	ES384 Algorithm = "ES384"
+	// EdDSA is Ed25519
+	EdDSA Algorithm = "EdDSA"
+)
+
+// Algorithms lists every supported signature algorithm
+var Algorithms = []Algorithm{RS256, PS256, PS512, ES256, ES384, EdDSA}
+
+// Checking the algorithm is supported
+func parseAlgorithm(value string) (Algorithm, error) {
+	for _, algorithm := range Algorithms {
+		if string(algorithm) == value {
+			return algorithm, nil
+		}
+	}
+	return "", errors.Errorf("Unsupported signature algorithm %q", value)
+}
+
+// Describing the kind of a public key (Eg: "ECDSA P-256")
+func keyType(key crypto.PublicKey) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA"
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return fmt.Sprintf("%T", key)
+}
+
+// Checking the key is supported: RSA, ECDSA (P-256 and P-384) and Ed25519
+func checkKeySupported(key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() || k.Curve == elliptic.P384() {
+			return nil
+		}
+	}
+	return newError(ErrInvalidKey, nil, `Unsupported public key type %s`, keyType(key))
+}
+
+// Checking the key is suited for the algorithm, so that a key can't be used with another algorithm
+func checkKeyAlgorithm(algorithm Algorithm, key crypto.PublicKey) error {
+	suited := false
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		suited = algorithm == RS256 || algorithm == PS256 || algorithm == PS512
+	case *ecdsa.PublicKey:
+		suited = (algorithm == ES256 && k.Curve == elliptic.P256()) || (algorithm == ES384 && k.Curve == elliptic.P384())
+	case ed25519.PublicKey:
+		suited = algorithm == EdDSA
+	}
+	if !suited {
+		return newError(ErrInvalidSignature, nil, "Algorithm %s can't be verified with a %s key", algorithm, keyType(key))
+	}
+	return nil
+}
+
+// Checking the algorithm is part of the accepted ones (every algorithm is accepted when none is given)
+func checkAlgorithmAllowed(algorithm Algorithm, allowed []Algorithm) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, allowedAlgorithm := range allowed {
+		if algorithm == allowedAlgorithm {
+			return nil
+		}
+	}
+	return newError(ErrInvalidSignature, nil, "Algorithm %s is not accepted (accepted: %v)", algorithm, allowed)
+}
+
+// Verifying the signature of a message with the given algorithm and key
+func verify(algorithm Algorithm, key crypto.PublicKey, message []byte, signature []byte) error {
+	if err := checkKeyAlgorithm(algorithm, key); err != nil {
+		return err
+	}
+	var err error
+	switch algorithm {
+	case RS256:
+		hash := sha256.Sum256(message)
+		err = rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, hash[:], signature)
+	case PS256:
+		hash := sha256.Sum256(message)
+		err = rsa.VerifyPSS(key.(*rsa.PublicKey), crypto.SHA256, hash[:], signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	case PS512:
+		hash := sha512.Sum512(message)
+		err = rsa.VerifyPSS(key.(*rsa.PublicKey), crypto.SHA512, hash[:], signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	case ES256:
+		hash := sha256.Sum256(message)
+		if !ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), hash[:], signature) {
+			err = errSignatureMismatch
+		}
+	case ES384:
+		hash := sha512.Sum384(message)
+		if !ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), hash[:], signature) {
+			err = errSignatureMismatch
+		}
+	case EdDSA:
+		if !ed25519.Verify(key.(ed25519.PublicKey), message, signature) {
+			err = errSignatureMismatch
+		}
+	}
+	if err != nil {
+		return newError(ErrInvalidSignature, err, `Unable to verify license`)
+	}
+	return nil
+}
+
+// Signing a license message with the algorithm (the key must be suited for it)
+func sign(algorithm Algorithm, key crypto.Signer, message []byte) ([]byte, error) {
+	if err := checkKeyAlgorithm(algorithm, key.Public()); err != nil {
+		return nil, newError(ErrInvalidKey, nil, "Algorithm %s can't be signed with a %s key", algorithm, keyType(key.Public()))
+	}
+	var signature []byte
+	var err error
+	switch algorithm {
+	case RS256:
+		hash := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	case PS256:
+		hash := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, hash[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	case PS512:
+		hash := sha512.Sum512(message)
+		signature, err = key.Sign(rand.Reader, hash[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA512})
+	case ES256:
+		hash := sha256.Sum256(message)
+		signature, err = key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	case ES384:
+		hash := sha512.Sum384(message)
+		signature, err = key.Sign(rand.Reader, hash[:], crypto.SHA384)
+	case EdDSA:
+		signature, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	if err != nil {
+		return nil, newError(ErrInvalidKey, err, "Unable to sign license")
+	}
+	return signature, nil
+}
+
+// Choosing the algorithm of a key when none is given (RSA keys use RS256, readable by every release)
+func defaultAlgorithm(key crypto.PublicKey) (Algorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return RS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return ES256, nil
+		case elliptic.P384():
+			return ES384, nil
+		}
+	case ed25519.PublicKey:
+		return EdDSA, nil
+	}
+	return "", newError(ErrInvalidKey, nil, "Unsupported %s key", keyType(key))
+}
+
+// Signing a payload (Eg: a revocation list) with the algorithm of the key; the signature is base64 encoded
+func signPayload(key crypto.Signer, payload []byte) (Algorithm, string, error) {
+	algorithm, err := defaultAlgorithm(key.Public())
+	if err != nil {
+		return "", "", err
+	}
+	signature, err := sign(algorithm, key, payload)
+	if err != nil {
+		return "", "", err
+	}
+	return algorithm, base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verifying the base64 signature of a payload with the trusted key selected by its key ID, the same way as licenses;
+// payloads carry no trusted signing date before being verified, so the key must be trusted at the current time
+func verifyPayload(payload []byte, algorithm Algorithm, keyID string, signature string, keyring *Keyring, o *options) error {
+	if keyring == nil {
+		return newError(ErrInvalidKey, nil, "No key loaded to verify the signature")
+	}
+	if _, err := parseAlgorithm(string(algorithm)); err != nil {
+		return newError(ErrInvalidFormat, err, "Invalid signature algorithm")
+	}
+	if err := checkAlgorithmAllowed(algorithm, o.allowedAlgorithms); err != nil {
+		return err
+	}
+	key, err := keyring.Lookup(keyID, o.clock(), o.clock())
+	if err != nil {
+		return err
+	}
+	if key.Algorithm != "" && key.Algorithm != algorithm {
+		return newError(ErrInvalidSignature, nil, "Key ID %q is restricted to algorithm %s, got %s", key.ID, key.Algorithm, algorithm)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return newError(ErrInvalidSignature, err, "Unable to decode base64 signature")
+	}
+	return verify(algorithm, key.Key, payload, decoded)
+}
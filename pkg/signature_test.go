@@ -0,0 +1,176 @@
+package license
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Encoding a public key as PEM (PKIX)
+func testPublicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Building a license made of the given headers, signed with the given algorithm and key
+func testSignLicense(t *testing.T, algorithm Algorithm, key crypto.Signer, headers string) string {
+	message := "====BEGIN LICENSE====\n" + headers + "\n=====END LICENSE====="
+	var signature []byte
+	var err error
+	switch algorithm {
+	case RS256:
+		hash := sha256.Sum256([]byte(message))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, hash[:])
+	case PS256:
+		hash := sha256.Sum256([]byte(message))
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, hash[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case PS512:
+		hash := sha512.Sum512([]byte(message))
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA512, hash[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case ES256:
+		hash := sha256.Sum256([]byte(message))
+		signature, err = ecdsa.SignASN1(rand.Reader, key.(*ecdsa.PrivateKey), hash[:])
+	case ES384:
+		hash := sha512.Sum384([]byte(message))
+		signature, err = ecdsa.SignASN1(rand.Reader, key.(*ecdsa.PrivateKey), hash[:])
+	case EdDSA:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(message))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message + "\n====BEGIN SIGNATURE====\n" + base64.StdEncoding.EncodeToString(signature) + "\n====END SIGNATURE===="
+}
+
+func TestSignatureAlgorithms(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[Algorithm]crypto.Signer{
+		RS256: rsaKey,
+		ES256: p256Key,
+		ES384: p384Key,
+		EdDSA: ed25519Key,
+	}
+	for algorithm, key := range keys {
+		content := testSignLicense(t, algorithm, key, "Serial: foo\nMetadata: {}\nAlgorithm: "+string(algorithm))
+		license, err := New(content, WithPublicKey(testPublicKeyPEM(t, key.Public())))
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if license.Algorithm != algorithm {
+			t.Errorf("%s: got algorithm %s", algorithm, license.Algorithm)
+		}
+		if _, err := license.Validate(nil); err != nil {
+			t.Errorf("%s: expected a valid license, got %v", algorithm, err)
+		}
+		// A key must not be usable with another algorithm
+		for otherAlgorithm, otherKey := range keys {
+			if otherAlgorithm == algorithm {
+				continue
+			}
+			license, err := New(content, WithPublicKey(testPublicKeyPEM(t, otherKey.Public())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("%s license with %s key: expected ErrInvalidSignature, got %v", algorithm, otherAlgorithm, err)
+			}
+		}
+	}
+	// Licenses without "Algorithm" header are RSA PKCS#1 v1.5
+	content := testSignLicense(t, EdDSA, ed25519Key, "Serial: foo\nMetadata: {}")
+	license, err := New(content, WithPublicKey(testPublicKeyPEM(t, ed25519Key.Public())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature for an Ed25519 key without algorithm, got %v", err)
+	}
+	// Unsupported algorithm and key
+	content = testSignLicense(t, EdDSA, ed25519Key, "Serial: foo\nAlgorithm: HS256")
+	if _, err := New(content, WithPublicKey(testPublicKeyPEM(t, ed25519Key.Public()))); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat for an unsupported algorithm, got %v", err)
+	}
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = testSignLicense(t, EdDSA, ed25519Key, "Serial: foo\nAlgorithm: EdDSA")
+	if _, err := New(content, WithPublicKey(testPublicKeyPEM(t, p521Key.Public()))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a P-521 key, got %v", err)
+	}
+}
+
+func TestRSAPSS(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := testPublicKeyPEM(t, rsaKey.Public())
+	// RSA-PSS licenses and legacy PKCS#1 v1.5 ones are accepted by the migration policy
+	for _, algorithm := range []Algorithm{PS256, PS512, RS256} {
+		content := testSignLicense(t, algorithm, rsaKey, "Serial: foo\nAlgorithm: "+string(algorithm))
+		license, err := New(content, WithPublicKey(publicKey), WithAllowedAlgorithms(PS256, PS512, RS256))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := license.Validate(nil); err != nil {
+			t.Errorf("%s: expected a valid license, got %v", algorithm, err)
+		}
+	}
+	// A PKCS#1 v1.5 signature can't pass for a PSS one
+	content := testSignLicense(t, RS256, rsaKey, "Serial: foo\nAlgorithm: PS256")
+	license, err := New(content, WithPublicKey(publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+	// Legacy licenses are refused once the policy accepts PSS only
+	content = testSignLicense(t, RS256, rsaKey, "Serial: foo")
+	license, err = New(content, WithPublicKey(publicKey), WithAllowedAlgorithms(PS256, PS512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := license.Validate(nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature for a refused algorithm, got %v", err)
+	}
+	// Invalid policies
+	for _, opt := range []Option{WithAllowedAlgorithms(), WithAllowedAlgorithms("HS256")} {
+		if _, err := New(content, WithPublicKey(publicKey), opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Expected ErrInvalidOption, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package license
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/rest"
+)
+
+// LicenseSource provides the raw content of a license
+type LicenseSource interface {
+	ReadLicense(ctx context.Context) ([]byte, error)
+}
+
+// KeySource provides the raw content (PEM) of a public key
+type KeySource interface {
+	ReadKey(ctx context.Context) ([]byte, error)
+}
+
+// Source is both a LicenseSource and a KeySource (as every built-in source)
+type Source interface {
+	LicenseSource
+	KeySource
+}
+
+// SourceFunc adapts a function to both LicenseSource and KeySource
+type SourceFunc func(ctx context.Context) ([]byte, error)
+
+// ReadLicense calls f(ctx)
+func (f SourceFunc) ReadLicense(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// ReadKey calls f(ctx)
+func (f SourceFunc) ReadKey(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// FromFile reads the content from a file
+func FromFile(path string) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, newError(ErrFetch, err, "Unable to read %q", path)
+		}
+		return content, nil
+	}
+}
+
+// FromString offers the content itself
+func FromString(content string) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		return []byte(content), nil
+	}
+}
+
+// FromReader reads the whole content from a reader (it can be read only once)
+func FromReader(reader io.Reader) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, newError(ErrFetch, err, "Unable to read content")
+		}
+		return content, nil
+	}
+}
+
+// FromEnv reads the content from an environment variable (it fails if the variable is not set)
+func FromEnv(name string) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		content, found := os.LookupEnv(name)
+		if !found {
+			return nil, newError(ErrFetch, nil, "Environment variable %q is not set", name)
+		}
+		return []byte(content), nil
+	}
+}
+
+// FromFS reads the content from a file system (Eg: an embed.FS)
+func FromFS(fsys fs.FS, name string) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, newError(ErrFetch, err, "Unable to read %q", name)
+		}
+		return content, nil
+	}
+}
+
+// FromEmbedded offers a public key embedded at build time (Eg: with -ldflags "-X ..."), written as PEM,
+// base64 encoded PEM or base64 encoded DER (PKIX); base64 avoids new lines, which -X doesn't support
+func FromEmbedded(value string) SourceFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		// The captured value is left untouched: the source may be read concurrently
+		trimmed := strings.TrimSpace(value)
+		if strings.HasPrefix(trimmed, "-----BEGIN") {
+			return []byte(trimmed), nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(trimmed), ""))
+		if err != nil {
+			return nil, newError(ErrInvalidKey, err, "Unable to decode embedded public key")
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(decoded), []byte("-----BEGIN")) {
+			return decoded, nil
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: decoded}), nil
+	}
+}
+
+// URLSource fetches the content from an URL
+// Unset fields are taken from the options given to NewFromSource (WithToken, WithHTTPClient, ...)
+type URLSource struct {
+	URL                string
+	Token              string
+	HTTPClient         *http.Client
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// FromURL fetches the content from an URL
+func FromURL(URL string) *URLSource {
+	return &URLSource{URL: URL}
+}
+
+// ReadLicense fetches the license
+func (s *URLSource) ReadLicense(ctx context.Context) ([]byte, error) {
+	return s.fetch(ctx)
+}
+
+// ReadKey fetches the public key
+func (s *URLSource) ReadKey(ctx context.Context) ([]byte, error) {
+	return s.fetch(ctx)
+}
+
+// Filling unset fields from options
+func (s *URLSource) withOptions(o *options) *URLSource {
+	configured := *s
+	if configured.Token == "" {
+		configured.Token = o.token
+	}
+	if configured.HTTPClient == nil {
+		configured.HTTPClient = o.httpClient
+	}
+	if configured.Timeout == 0 {
+		configured.Timeout = o.timeout
+	}
+	configured.InsecureSkipVerify = configured.InsecureSkipVerify || o.insecureSkipVerify
+	return &configured
+}
+
+func (s *URLSource) fetch(ctx context.Context) ([]byte, error) {
+	_, content, _, err := s.fetchConditional(ctx, "")
+	return content, err
+}
+
+// Fetching the content unless it still matches the given ETag (status 304 with no content)
+func (s *URLSource) fetchConditional(ctx context.Context, etag string) (int, []byte, string, error) {
+	headers, restOptions := s.request()
+	if etag != "" {
+		headers["If-None-Match"] = etag
+	}
+	status, content, responseHeaders, err := rest.GetWithContext(ctx, s.URL, headers, restOptions)
+	if err != nil {
+		return status, nil, "", newError(ErrFetch, err, "Unable to fetch %q", s.URL)
+	}
+	return status, content, responseHeaders["Etag"], nil
+}
+
+// Posting JSON data to the URL; the content of the response is offered even on failure (Eg: the reason of a refusal)
+func (s *URLSource) post(ctx context.Context, data map[string]interface{}) (int, []byte, error) {
+	headers, restOptions := s.request()
+	status, content, _, err := rest.PostWithContext(ctx, s.URL, data, headers, restOptions)
+	if err != nil {
+		return status, content, newError(ErrFetch, err, "Unable to post to %q", s.URL)
+	}
+	return status, content, nil
+}
+
+// Building the headers and the options of a request to the URL
+func (s *URLSource) request() (map[string]string, map[string]interface{}) {
+	headers := map[string]string{}
+	if s.Token != "" {
+		headers["Authorization"] = "Bearer " + s.Token
+	}
+	restOptions := map[string]interface{}{
+		"IgnoreInsecureSsl": s.InsecureSkipVerify,
+		"HTTPClient":        s.HTTPClient,
+	}
+	if s.Timeout > 0 {
+		restOptions["Timeout"] = s.Timeout
+	}
+	return headers, restOptions
+}
+
+// Reading a license from its source, configuring built-in sources with the options
+func readLicense(ctx context.Context, source LicenseSource, o *options) ([]byte, error) {
+	if urlSource, ok := source.(*URLSource); ok {
+		source = urlSource.withOptions(o)
+	}
+	return source.ReadLicense(ctx)
+}
+
+// Reading a key from its source, configuring built-in sources with the options
+func readKey(ctx context.Context, source KeySource, o *options) ([]byte, error) {
+	if urlSource, ok := source.(*URLSource); ok {
+		source = urlSource.withOptions(o)
+	}
+	return source.ReadKey(ctx)
+}
+
+// GuessSource offers the source of an argument which can be an URL, a path or the content itself
+// Prefer explicit sources (FromFile, FromURL, ...) when the kind of argument is known
+func GuessSource(arg string) Source {
+	if isURL(arg) {
+		return FromURL(arg)
+	}
+	if isPath(arg) {
+		return FromFile(arg)
+	}
+	// Otherwise arg is a string
+	return FromString(arg)
+}
+
+// Checking if a string is an URL
+func isURL(stringToCheck string) bool {
+	parsed, err := url.ParseRequestURI(stringToCheck)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// Checking if string is a valid path
+func isPath(stringToCheck string) bool {
+	_, err := os.Stat(stringToCheck)
+	if err != nil {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package license
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+	"github.com/pkg/errors"
+)
+
+func TestSources(t *testing.T) {
+	logger.LogInit(logger.PanicLevel, false)
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := os.ReadFile("../test/assets/public.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUYMINT_TEST_LICENSE", string(content))
+	fsys := fstest.MapFS{"keys/public.key": &fstest.MapFile{Data: publicKey}}
+	// Custom source
+	var custom SourceFunc = func(ctx context.Context) ([]byte, error) {
+		return content, nil
+	}
+	sources := map[string]LicenseSource{
+		"file":   FromFile("../test/assets/license.txt"),
+		"string": FromString(string(content)),
+		"reader": FromReader(bytes.NewReader(content)),
+		"env":    FromEnv("BUYMINT_TEST_LICENSE"),
+		"custom": custom,
+	}
+	for name, source := range sources {
+		license, err := NewFromSource(context.Background(), source, WithKeySource(FromFS(fsys, "keys/public.key")))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if license.Serial != "foo-test-alpha" {
+			t.Errorf("%s: unexpected serial %q", name, license.Serial)
+		}
+	}
+	// Missing contents are fetch failures
+	missing := map[string]LicenseSource{
+		"file": FromFile("../test/assets/missing.txt"),
+		"env":  FromEnv("BUYMINT_TEST_MISSING"),
+		"fs":   FromFS(fsys, "missing.txt"),
+	}
+	for name, source := range missing {
+		if _, err := NewFromSource(context.Background(), source, WithPublicKey(string(publicKey))); !errors.Is(err, ErrFetch) {
+			t.Errorf("%s: expected ErrFetch, got %v", name, err)
+		}
+	}
+}
+
+func TestGuessSource(t *testing.T) {
+	if _, ok := GuessSource("https://buy.bmint.it/license").(*URLSource); !ok {
+		t.Error("Expected an URL source for an https URL")
+	}
+	// Absolute paths are valid request URIs but not URLs
+	if _, ok := GuessSource("/nonexistent/license.txt").(*URLSource); ok {
+		t.Error("Expected a path not to be guessed as an URL")
+	}
+	source := GuessSource("Serial: foo")
+	if content, err := source.ReadLicense(context.Background()); err != nil || string(content) != "Serial: foo" {
+		t.Errorf("Expected inline content, got %q (%v)", content, err)
+	}
+}
+
+func TestFromEmbedded(t *testing.T) {
+	publicKey, err := os.ReadFile("../test/assets/public.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		t.Fatal("Invalid test public key")
+	}
+	content, err := os.ReadFile("../test/assets/license.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded := map[string]string{
+		"pem":        string(publicKey),
+		"base64 pem": base64.StdEncoding.EncodeToString(publicKey),
+		"base64 der": base64.StdEncoding.EncodeToString(block.Bytes),
+	}
+	for name, value := range embedded {
+		if _, err := New(string(content), WithKeySource(FromEmbedded(value))); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := New(string(content), WithKeySource(FromEmbedded("not base64!"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	// The source is shared by concurrent readers (Eg: audit workers)
+	source := FromEmbedded("\n" + embedded["base64 pem"] + "\n")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := source.ReadKey(context.Background())
+			if err != nil || !bytes.Equal(bytes.TrimSpace(key), bytes.TrimSpace(publicKey)) {
+				t.Errorf("Expected the embedded key, got %q (%v)", key, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,127 @@
+package license
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Clevermind-Think-Mint/buymint-cli-go/internal/logger"
+)
+
+// Status summarizes where the license stands in its validity window
+type Status string
+
+const (
+	// StatusValid means the license is valid and not close to its expiration
+	StatusValid Status = "valid"
+	// StatusExpiringSoon means the license is valid but expires within the warning period
+	StatusExpiringSoon Status = "expiring_soon"
+	// StatusInGrace means the license is expired but still accepted within the grace period
+	StatusInGrace Status = "in_grace"
+	// StatusExpired means the license is expired and the grace period (if any) is over
+	StatusExpired Status = "expired"
+	// StatusNotYetValid means the license is used before it was signed
+	StatusNotYetValid Status = "not_yet_valid"
+)
+
+// Validity describes the position of the license in its validity window at a given time
+type Validity struct {
+	Status Status    `json:"status"`
+	Now    time.Time `json:"now"`
+	// ExpiresOn is zero for perpetual licenses
+	ExpiresOn time.Time `json:"expires_on"`
+	// Remaining is the time left before "Expires on" (zero once expired or for perpetual licenses)
+	Remaining time.Duration `json:"remaining"`
+	// GraceRemaining is the time left before the end of the grace period (only set while in grace)
+	GraceRemaining time.Duration `json:"grace_remaining"`
+}
+
+// ExpiredError is returned when the license is validated after its "Expires on" date (and grace period)
+type ExpiredError struct {
+	ExpiresOn time.Time
+	Now       time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("License expired on %s (now: %s)", e.ExpiresOn.Format(time.RFC3339), e.Now.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrExpired) work
+func (e *ExpiredError) Is(target error) bool {
+	return target == ErrExpired
+}
+
+// NotYetValidError is returned when the license is validated before its "Signed on" date
+type NotYetValidError struct {
+	SignedOn time.Time
+	Now      time.Time
+}
+
+func (e *NotYetValidError) Error() string {
+	return fmt.Sprintf("License is not valid before %s (now: %s)", e.SignedOn.Format(time.RFC3339), e.Now.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrNotYetValid) work
+func (e *NotYetValidError) Is(target error) bool {
+	return target == ErrNotYetValid
+}
+
+// Validity reports the status of the license at current time, tolerating the configured clock skew
+// It doesn't verify the signature: use Validate to ensure the license is authentic
+func (t *License) Validity() Validity {
+	now := t.now()
+	validity := Validity{
+		Status:    StatusValid,
+		Now:       now,
+		ExpiresOn: t.ExpiresOn,
+	}
+	// A zero "Signed on" means no lower bound
+	if !t.SignedOn.IsZero() && now.Add(t.clockSkew).Before(t.SignedOn) {
+		validity.Status = StatusNotYetValid
+		return validity
+	}
+	// A zero "Expires on" means a perpetual license
+	if t.ExpiresOn.IsZero() {
+		return validity
+	}
+	if !now.Add(-t.clockSkew).After(t.ExpiresOn) {
+		if now.Before(t.ExpiresOn) {
+			validity.Remaining = t.ExpiresOn.Sub(now)
+		}
+		if validity.Remaining < t.warningPeriod {
+			validity.Status = StatusExpiringSoon
+		}
+		return validity
+	}
+	graceEnd := t.ExpiresOn.Add(t.gracePeriod).Add(t.clockSkew)
+	if now.After(graceEnd) {
+		validity.Status = StatusExpired
+		return validity
+	}
+	validity.Status = StatusInGrace
+	validity.GraceRemaining = graceEnd.Sub(now)
+	return validity
+}
+
+// Checking the license is inside its validity window (grace period included)
+func (t *License) checkValidityWindow() error {
+	validity := t.Validity()
+	switch validity.Status {
+	case StatusNotYetValid:
+		return &NotYetValidError{SignedOn: t.SignedOn, Now: validity.Now}
+	case StatusExpired:
+		return &ExpiredError{ExpiresOn: t.ExpiresOn, Now: validity.Now}
+	case StatusInGrace:
+		logger.Warn("License expired on %s; grace period ends in %s", t.ExpiresOn.Format(time.RFC3339), validity.GraceRemaining)
+	case StatusExpiringSoon:
+		logger.Warn("License expires in %s", validity.Remaining)
+	}
+	return nil
+}
+
+// Getting current time from the configured clock
+func (t *License) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock()
+}
@@ -0,0 +1,51 @@
+package license
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidity(t *testing.T) {
+	expiresOn := time.Date(2022, 5, 29, 15, 2, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	license := &License{
+		SignedOn:      time.Date(2022, 4, 29, 15, 3, 37, 0, time.UTC),
+		ExpiresOn:     expiresOn,
+		gracePeriod:   7 * day,
+		warningPeriod: 10 * day,
+	}
+	cases := []struct {
+		now            time.Time
+		status         Status
+		remaining      time.Duration
+		graceRemaining time.Duration
+	}{
+		{now: expiresOn.Add(-20 * day), status: StatusValid, remaining: 20 * day},
+		{now: expiresOn.Add(-3 * day), status: StatusExpiringSoon, remaining: 3 * day},
+		{now: expiresOn.Add(2 * day), status: StatusInGrace, graceRemaining: 5 * day},
+		{now: expiresOn.Add(8 * day), status: StatusExpired},
+		{now: expiresOn.Add(-60 * day), status: StatusNotYetValid},
+	}
+	for _, c := range cases {
+		now := c.now
+		license.clock = func() time.Time { return now }
+		validity := license.Validity()
+		if validity.Status != c.status || validity.Remaining != c.remaining || validity.GraceRemaining != c.graceRemaining {
+			t.Errorf("At %s: got %s (remaining %s, grace %s), want %s (remaining %s, grace %s)", now, validity.Status, validity.Remaining, validity.GraceRemaining, c.status, c.remaining, c.graceRemaining)
+		}
+	}
+	// The grace period keeps the license working, the end of grace doesn't
+	license.clock = func() time.Time { return expiresOn.Add(2 * day) }
+	if err := license.checkValidityWindow(); err != nil {
+		t.Errorf("Expected no error in grace period, got %v", err)
+	}
+	license.clock = func() time.Time { return expiresOn.Add(8 * day) }
+	if _, ok := license.checkValidityWindow().(*ExpiredError); !ok {
+		t.Error("Expected an ExpiredError after the grace period")
+	}
+	// Perpetual licenses never expire
+	perpetual := &License{clock: license.clock}
+	if validity := perpetual.Validity(); validity.Status != StatusValid {
+		t.Errorf("Expected a perpetual license to be valid, got %s", validity.Status)
+	}
+}